@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/petechu/idempotent-webhook-relay/internal/config"
+	"github.com/petechu/idempotent-webhook-relay/internal/consumer"
+	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/db/migrations"
+	"github.com/petechu/idempotent-webhook-relay/internal/delivery"
+	"github.com/petechu/idempotent-webhook-relay/internal/handler"
+	"github.com/petechu/idempotent-webhook-relay/internal/producer"
+	"github.com/petechu/idempotent-webhook-relay/internal/queue"
+	"github.com/petechu/idempotent-webhook-relay/internal/svc"
+)
+
+// relay runs the webhook, producer and consumer services in one process.
+// With BROKER=memory or BROKER=postgres it needs nothing but Postgres.
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Unable to load config: %v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	poolCfg, err := cfg.DatabasePoolConfig()
+	if err != nil {
+		log.Fatalf("Unable to parse database config: %v\n", err)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		log.Fatalf("Unable to connect to database: %v\n", err)
+	}
+	defer pool.Close()
+
+	if err := migrations.Up(ctx, cfg.DatabaseURL()); err != nil {
+		log.Fatalf("Migration failed: %v\n", err)
+	}
+
+	svcCtx, err := svc.NewServiceContext(cfg, pool)
+	if err != nil {
+		log.Fatalf("Unable to create service context: %v\n", err)
+	}
+
+	router := gin.New()
+	router.Use(gin.Logger())
+	router.Use(gin.Recovery())
+	handler.RegisterRoutes(router, svcCtx)
+
+	server := http.Server{
+		Addr:    ":3000",
+		Handler: router,
+	}
+
+	query := db.New(pool)
+	c := consumer.Consumer{
+		Context:    ctx,
+		Pool:       pool,
+		DB:         query,
+		Deliverer:  delivery.NewDeliverer(cfg),
+		MaxRetries: cfg.ConsumerMaxRetries,
+		// Matches the MaxDeliver of the NATS consumer.
+		MaxDeliveries: cfg.ConsumerMaxRetries + 1,
+	}
+
+	if cfg.Broker == queue.BrokerPostgres {
+		go c.RunFromOutbox(cfg, cfg.ConsumerRequeueOnFailure)
+	} else {
+		broker, err := queue.Open(ctx, cfg)
+		if err != nil {
+			log.Fatalf("Unable to open %s broker: %v\n", cfg.Broker, err)
+		}
+		defer broker.Close()
+		svcCtx.Broker = broker
+
+		p := producer.NewProducer(ctx, cfg, query, broker)
+		if cfg.Broker == queue.BrokerMemory {
+			// Messages published by a previous run were lost on exit.
+			if err := p.RecoverPending(ctx); err != nil {
+				log.Fatalf("Unable to recover pending events: %v\n", err)
+			}
+		}
+		go p.Run(ctx)
+		go c.Run(broker, cfg.ConsumerWorkers, cfg.ConsumerRequeueOnFailure)
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+
+	fmt.Printf("Listening on port: %s (broker: %s)\n", server.Addr, cfg.Broker)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v\n", err)
+		}
+	}()
+
+	<-ch
+
+	fmt.Println("Gracefully shutting down relay...")
+	cancel()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Fatalf("Error closing server: %v\n", err)
+	}
+	fmt.Println("Relay shut down successfully.")
+}
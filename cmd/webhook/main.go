@@ -2,24 +2,20 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/petechu/idempotent-webhook-relay/internal/config"
 	"github.com/petechu/idempotent-webhook-relay/internal/db/migrations"
 	"github.com/petechu/idempotent-webhook-relay/internal/handler"
 	"github.com/petechu/idempotent-webhook-relay/internal/svc"
-	"github.com/pressly/goose/v3"
 )
 
 func main() {
@@ -37,21 +33,25 @@ func main() {
 
 	ctx := context.Background()
 
-	conn, err := pgx.Connect(ctx, cfg.DatabaseURL())
+	poolCfg, err := cfg.DatabasePoolConfig()
+	if err != nil {
+		log.Fatalf("Unable to parse database config: %v\n", err)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		log.Fatalf("Unable to connect to database: %v\n", err)
 	}
-	defer func() {
-		if err := conn.Close(ctx); err != nil {
-			log.Printf("Error closing database connection: %v\n", err)
-		}
-	}()
+	defer pool.Close()
 
-	if err := migrate(ctx, cfg.DatabaseURL()); err != nil {
+	if err := migrations.Up(ctx, cfg.DatabaseURL()); err != nil {
 		log.Fatalf("Migration failed: %v\n", err)
 	}
 
-	svcCtx := svc.NewServiceContext(cfg, conn)
+	svcCtx, err := svc.NewServiceContext(cfg, pool)
+	if err != nil {
+		log.Fatalf("Unable to create service context: %v\n", err)
+	}
 	handler.RegisterRoutes(router, svcCtx)
 
 	server := http.Server{
@@ -79,30 +79,3 @@ func main() {
 	}
 	fmt.Println("Server shut down successfully.")
 }
-
-func migrate(ctx context.Context, dbURL string) error {
-	db, err := sql.Open("pgx", dbURL)
-	if err != nil {
-		log.Fatalf("Unable to open database connection: %v\n", err)
-	}
-
-	provider, err := goose.NewProvider(goose.DialectPostgres, db, migrations.Embed)
-	if err != nil {
-		return err
-	}
-
-	sources := provider.ListSources()
-	for _, s := range sources {
-		log.Printf("%-3s %-2v %v\n", s.Type, s.Version, filepath.Base(s.Path))
-	}
-
-	results, err := provider.Up(ctx)
-	if err != nil {
-		return err
-	}
-	for _, r := range results {
-		log.Printf("%-3s %-2v done: %v\n", r.Source.Type, r.Source.Version, r.Duration)
-	}
-
-	return nil
-}
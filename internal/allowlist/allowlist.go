@@ -0,0 +1,37 @@
+package allowlist
+
+import "path"
+
+// Allowlist decides which event types are relayed. Patterns use path.Match
+// syntax, so "invoice.*" matches "invoice.paid" and
+// "customer.subscription.*" matches "customer.subscription.updated".
+type Allowlist struct {
+	defaults  []string
+	providers map[string][]string
+}
+
+// New builds an allowlist where providers without their own list fall back
+// to defaults.
+func New(defaults []string, providers map[string][]string) *Allowlist {
+	return &Allowlist{
+		defaults:  defaults,
+		providers: providers,
+	}
+}
+
+func (a *Allowlist) Allows(provider, eventType string) bool {
+	patterns, ok := a.providers[provider]
+	if !ok {
+		patterns = a.defaults
+	}
+	return MatchAny(patterns, eventType)
+}
+
+func MatchAny(patterns []string, eventType string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, eventType); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package config
+
+import "testing"
+
+func TestEventTypeAllowlist(t *testing.T) {
+	tests := []struct {
+		name       string
+		eventTypes []string
+		allowlists []ProviderAllowlist
+		provider   string
+		eventType  string
+		want       bool
+	}{
+		{name: "stripe default", provider: "stripe", eventType: "payment_intent.succeeded", want: true},
+		{name: "stripe default excludes others", provider: "stripe", eventType: "invoice.paid"},
+		{name: "other providers relay everything", provider: "github", eventType: "push", want: true},
+		{
+			name:       "stripe override",
+			allowlists: []ProviderAllowlist{{Provider: "stripe", EventTypes: []string{"invoice.*"}}},
+			provider:   "stripe",
+			eventType:  "invoice.paid",
+			want:       true,
+		},
+		{
+			name:       "explicit defaults apply to stripe",
+			eventTypes: []string{"invoice.*"},
+			provider:   "stripe",
+			eventType:  "invoice.paid",
+			want:       true,
+		},
+		{
+			name:       "explicit defaults apply to other providers",
+			eventTypes: []string{"invoice.*"},
+			provider:   "github",
+			eventType:  "push",
+		},
+		{
+			name:       "provider override",
+			eventTypes: []string{"invoice.*"},
+			allowlists: []ProviderAllowlist{{Provider: "github", EventTypes: []string{"push"}}},
+			provider:   "github",
+			eventType:  "push",
+			want:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{ProducerEventTypes: tt.eventTypes, ProducerAllowlists: tt.allowlists}
+			if got := c.EventTypeAllowlist().Allows(tt.provider, tt.eventType); got != tt.want {
+				t.Fatalf("Allows(%q, %q) = %v, want %v", tt.provider, tt.eventType, got, tt.want)
+			}
+		})
+	}
+}
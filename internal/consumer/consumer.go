@@ -0,0 +1,243 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/delivery"
+	"github.com/petechu/idempotent-webhook-relay/internal/queue"
+)
+
+type Consumer struct {
+	Context    context.Context
+	Pool       *pgxpool.Pool
+	DB         *db.Queries
+	Deliverer  *delivery.Deliverer
+	MaxRetries int
+	// MaxDeliveries is the broker delivery after which a failed event is
+	// dead-lettered even when requeueing, on brokers that count deliveries
+	// and stop redelivering at a limit, such as NATS. Zero never stops.
+	MaxDeliveries int
+}
+
+type job struct {
+	event db.Outbox
+	msg   queue.Delivery
+}
+
+// Run processes deliveries from sub with workerCount concurrent workers until
+// the subscription ends. Exhausted events are requeued when requeue is set
+// and dead-lettered otherwise, or once the broker's last delivery failed. A
+// requeued event stays pending so that the producer does not publish a
+// second copy while the broker redelivers it.
+func (c Consumer) Run(sub queue.Subscriber, workerCount int, requeue bool) {
+	jobs := make(chan job)
+
+	for range workerCount {
+		go func() {
+			for j := range jobs {
+				if err := c.process(j.event); err != nil {
+					if requeue && !c.lastDelivery(j.msg) {
+						c.processRequeued(j.event.ID, err)
+						nack(j.msg, true)
+					} else {
+						c.processFailed(j.event.ID, err)
+						c.deadLetter(j, err)
+					}
+				} else {
+					ack(j.msg)
+				}
+			}
+		}()
+	}
+
+	err := sub.Subscribe(c.Context, func(ctx context.Context, msg queue.Delivery) {
+		log.Printf("Received a message: %s", msg.Body)
+
+		message := db.Outbox{}
+		if err := json.Unmarshal(msg.Body, &message); err != nil {
+			log.Printf("Failed to unmarshal message body: %s", err)
+			nack(msg, false)
+			return
+		}
+
+		idempotencyKey := message.EventID
+		event, err := c.DB.GetOutBoxEvent(ctx, db.GetOutBoxEventParams{
+			Provider: message.Provider,
+			EventID:  idempotencyKey,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Event %s not found in the outbox", idempotencyKey)
+			nack(msg, false)
+			return
+		}
+		if err != nil {
+			// The row is still pending, so the broker's redelivery is the
+			// only copy of the message.
+			log.Printf("Failed to load event %s: %v", idempotencyKey, err)
+			nack(msg, true)
+			return
+		}
+
+		jobs <- job{event: event, msg: msg}
+	})
+	if err != nil {
+		log.Printf("Subscriber stopped: %v", err)
+	}
+	close(jobs)
+}
+
+func (c Consumer) lastDelivery(msg queue.Delivery) bool {
+	return c.MaxDeliveries > 0 && msg.Attempt >= c.MaxDeliveries
+}
+
+// deadLetter records the exhausted event in the dead_letters table and routes
+// the message to the broker's dead-letter destination with failure metadata
+// headers.
+func (c Consumer) deadLetter(j job, err error) {
+	attempts, lastError := c.recordDeadLetter(j.event, err)
+
+	if dlErr := j.msg.DeadLetter(c.Context, map[string]any{
+		"x-event-id":   j.event.EventID,
+		"x-outbox-id":  j.event.ID,
+		"x-attempts":   attempts,
+		"x-last-error": lastError,
+		"x-failed-at":  time.Now().UTC().Format(time.RFC3339),
+	}); dlErr != nil {
+		log.Printf("Failed to dead-letter event %s: %v", j.event.EventID, dlErr)
+	}
+}
+
+// recordDeadLetter inserts a dead_letters row with the error of every attempt
+// joined into err and marks the event dead_lettered in the same transaction,
+// so that it is not claimed again until it is redriven. It returns the
+// attempt count and the last error.
+func (c Consumer) recordDeadLetter(event db.Outbox, err error) (int32, string) {
+	attempts := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		attempts = joined.Unwrap()
+	}
+
+	attemptErrors := make([]string, len(attempts))
+	for i, attemptErr := range attempts {
+		attemptErrors[i] = attemptErr.Error()
+	}
+	lastError := attemptErrors[len(attemptErrors)-1]
+
+	if txErr := c.insertDeadLetter(db.InsertDeadLetterParams{
+		OutboxID: event.ID,
+		EventID:  event.EventID,
+		Payload:  event.Payload,
+		Attempts: int32(len(attemptErrors)),
+		Errors:   attemptErrors,
+		LastError: pgtype.Text{
+			Valid:  true,
+			String: lastError,
+		},
+	}); txErr != nil {
+		log.Printf("Failed to insert dead letter for event %s: %v", event.EventID, txErr)
+	}
+	return int32(len(attemptErrors)), lastError
+}
+
+func (c Consumer) insertDeadLetter(arg db.InsertDeadLetterParams) error {
+	tx, err := c.Pool.Begin(c.Context)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(c.Context)
+
+	query := c.DB.WithTx(tx)
+	if _, err := query.InsertDeadLetter(c.Context, arg); err != nil {
+		return err
+	}
+	if err := query.MarkOutboxDeadLettered(c.Context, arg.OutboxID); err != nil {
+		return fmt.Errorf("failed to mark outbox event as dead-lettered: %w", err)
+	}
+	return tx.Commit(c.Context)
+}
+
+func ack(msg queue.Delivery) {
+	if err := msg.Ack(); err != nil {
+		log.Printf("Failed to ack message: %v", err)
+	}
+}
+
+func nack(msg queue.Delivery, requeue bool) {
+	if err := msg.Nack(requeue); err != nil {
+		log.Printf("Failed to nack message: %v", err)
+	}
+}
+
+func (c Consumer) processFailed(eventID int32, err error) {
+	updateErr := c.DB.UpdateOutboxEvent(c.Context, db.UpdateOutboxEventParams{
+		ID: eventID,
+		Status: pgtype.Text{
+			Valid:  true,
+			String: "process_failed",
+		},
+		LastError: pgtype.Text{
+			Valid:  true,
+			String: err.Error(),
+		},
+		LastAttemptAt: pgtype.Timestamptz{
+			Valid: true,
+			Time:  time.Now(),
+		},
+	})
+	if updateErr != nil {
+		log.Printf("Failed to update outbox event (processFailed): %v", updateErr)
+	}
+}
+
+// processRequeued records err on an event whose message is requeued on the
+// broker and keeps the row pending, which the producer does not claim.
+func (c Consumer) processRequeued(eventID int32, err error) {
+	updateErr := c.DB.UpdateOutboxEvent(c.Context, db.UpdateOutboxEventParams{
+		ID: eventID,
+		Status: pgtype.Text{
+			Valid:  true,
+			String: "pending",
+		},
+		LastError: pgtype.Text{
+			Valid:  true,
+			String: err.Error(),
+		},
+		LastAttemptAt: pgtype.Timestamptz{
+			Valid: true,
+			Time:  time.Now(),
+		},
+	})
+	if updateErr != nil {
+		log.Printf("Failed to update outbox event (processRequeued): %v", updateErr)
+	}
+}
+
+func (c Consumer) processSucceeded(eventID int32) {
+	updateErr := c.DB.UpdateOutboxEvent(c.Context, db.UpdateOutboxEventParams{
+		ID: eventID,
+		Status: pgtype.Text{
+			Valid:  true,
+			String: "processed",
+		},
+		LastError: pgtype.Text{
+			Valid:  true,
+			String: "",
+		},
+		LastAttemptAt: pgtype.Timestamptz{
+			Valid: true,
+			Time:  time.Now(),
+		},
+	})
+	if updateErr != nil {
+		log.Printf("Failed to update outbox event (processSucceeded): %v", updateErr)
+	}
+}
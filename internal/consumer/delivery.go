@@ -0,0 +1,212 @@
+package consumer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/petechu/idempotent-webhook-relay/internal/allowlist"
+	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/delivery"
+	"github.com/petechu/idempotent-webhook-relay/internal/utils"
+)
+
+// destination is a downstream endpoint an event is delivered to: a static
+// DELIVERY_URLS entry, or a matching subscription whose status for the event
+// is tracked in subscription_deliveries. Static URLs are not tracked, so they
+// receive an event again whenever it is redelivered or redriven and must
+// deduplicate on the Idempotency-Key header.
+type destination struct {
+	url       string
+	secret    string
+	delivery  *db.SubscriptionDelivery
+	delivered bool
+}
+
+// process fans event out to every destination with backoff and marks its
+// outbox row processed on success. A failure is left to the caller to record,
+// since how the event is retried depends on the transport. Destinations that
+// already accepted the event, including subscriptions that did so on an
+// earlier delivery of the same event, are not retried.
+func (c Consumer) process(event db.Outbox) error {
+	dests, err := c.destinations(event)
+	if err != nil {
+		return err
+	}
+	if len(dests) == 0 {
+		log.Printf("No destinations for event %s", event.EventID)
+	}
+
+	if err := utils.Backoff(
+		func() error { return c.deliver(event, dests) },
+		c.MaxRetries,
+	); err != nil {
+		return err
+	}
+	c.processSucceeded(event.ID)
+	return nil
+}
+
+func (c Consumer) destinations(event db.Outbox) ([]*destination, error) {
+	dests := make([]*destination, 0, len(c.Deliverer.URLs))
+	for _, url := range c.Deliverer.URLs {
+		dests = append(dests, &destination{url: url})
+	}
+
+	subs, err := c.DB.ListMatchingSubscriptions(c.Context, event.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	for _, sub := range subs {
+		if !allowlist.MatchAny(sub.EventTypes, event.Type) {
+			continue
+		}
+
+		if err := c.DB.EnsureSubscriptionDelivery(c.Context, db.EnsureSubscriptionDeliveryParams{
+			OutboxID:       event.ID,
+			SubscriptionID: sub.ID,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to track delivery to subscription %d: %w", sub.ID, err)
+		}
+		d, err := c.DB.GetSubscriptionDelivery(c.Context, db.GetSubscriptionDeliveryParams{
+			OutboxID:       event.ID,
+			SubscriptionID: sub.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to track delivery to subscription %d: %w", sub.ID, err)
+		}
+		if d.Status == "delivered" {
+			continue
+		}
+		dests = append(dests, &destination{
+			url:      sub.Url,
+			secret:   sub.Secret.String,
+			delivery: &d,
+		})
+	}
+	return dests, nil
+}
+
+// deliver is one attempt: it POSTs event to every destination that has not
+// accepted it yet, records each request in delivery_attempts and the latest
+// response on the outbox row. The returned error is permanent only if every
+// failed destination rejected the event permanently.
+func (c Consumer) deliver(event db.Outbox, dests []*destination) error {
+	pending := make([]*destination, 0, len(dests))
+	for _, d := range dests {
+		if !d.delivered {
+			pending = append(pending, d)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// Without the attempt number the delivery_attempts rows could not be
+	// told apart, so fail the attempt before posting anything.
+	attempt, err := c.DB.IncrementOutboxRetryCount(c.Context, event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to increment retry count: %w", err)
+	}
+
+	var (
+		errs      []error
+		permanent = true
+	)
+	for _, d := range pending {
+		startedAt := time.Now()
+		resp, err := c.Deliverer.Post(c.Context, d.url, d.secret, event)
+		c.recordAttempt(event.ID, attempt, d, startedAt, time.Now(), resp, err)
+		if resp != nil {
+			c.recordResponse(event.ID, resp)
+		}
+		if d.delivery != nil {
+			c.recordDelivery(d.delivery.ID, resp, err)
+		}
+		if err != nil {
+			var permanentErr *utils.PermanentError
+			permanent = permanent && errors.As(err, &permanentErr)
+			errs = append(errs, err)
+			continue
+		}
+		d.delivered = true
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	err = errors.Join(errs...)
+	if permanent {
+		return utils.Permanent(err)
+	}
+	return err
+}
+
+func (c Consumer) recordResponse(eventID int32, resp *delivery.Response) {
+	if err := c.DB.RecordOutboxResponse(c.Context, db.RecordOutboxResponseParams{
+		ID: eventID,
+		ResponseStatus: pgtype.Int4{
+			Valid: true,
+			Int32: int32(resp.StatusCode),
+		},
+		ResponseBody: pgtype.Text{
+			Valid:  true,
+			String: resp.Body,
+		},
+	}); err != nil {
+		log.Printf("Failed to record response for event %d: %v", eventID, err)
+	}
+}
+
+func (c Consumer) recordAttempt(eventID, attempt int32, d *destination, startedAt, finishedAt time.Time, resp *delivery.Response, deliverErr error) {
+	arg := db.InsertDeliveryAttemptParams{
+		OutboxID:   eventID,
+		Url:        d.url,
+		Attempt:    attempt,
+		StartedAt:  pgtype.Timestamptz{Valid: true, Time: startedAt},
+		FinishedAt: pgtype.Timestamptz{Valid: true, Time: finishedAt},
+		LatencyMs:  int32(finishedAt.Sub(startedAt).Milliseconds()),
+		Outcome:    "succeeded",
+	}
+	if d.delivery != nil {
+		arg.SubscriptionID = pgtype.Int4{Valid: true, Int32: d.delivery.SubscriptionID}
+	}
+	if resp != nil {
+		arg.HttpStatus = pgtype.Int4{Valid: true, Int32: int32(resp.StatusCode)}
+	}
+	if deliverErr != nil {
+		arg.Outcome = "failed"
+		var permanentErr *utils.PermanentError
+		if errors.As(deliverErr, &permanentErr) {
+			arg.Outcome = "failed_permanently"
+		}
+		arg.Error = pgtype.Text{Valid: true, String: deliverErr.Error()}
+	}
+
+	if err := c.DB.InsertDeliveryAttempt(c.Context, arg); err != nil {
+		log.Printf("Failed to record attempt %d for event %d: %v", attempt, eventID, err)
+	}
+}
+
+func (c Consumer) recordDelivery(id int32, resp *delivery.Response, deliverErr error) {
+	arg := db.UpdateSubscriptionDeliveryParams{
+		ID:     id,
+		Status: "delivered",
+	}
+	if resp != nil {
+		arg.ResponseStatus = pgtype.Int4{Valid: true, Int32: int32(resp.StatusCode)}
+		arg.ResponseBody = pgtype.Text{Valid: true, String: resp.Body}
+	}
+	if deliverErr != nil {
+		arg.Status = "failed"
+		arg.LastError = pgtype.Text{Valid: true, String: deliverErr.Error()}
+	} else {
+		arg.DeliveredAt = pgtype.Timestamptz{Valid: true, Time: time.Now()}
+	}
+
+	if err := c.DB.UpdateSubscriptionDelivery(c.Context, arg); err != nil {
+		log.Printf("Failed to record delivery %d: %v", id, err)
+	}
+}
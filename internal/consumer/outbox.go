@@ -0,0 +1,124 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/petechu/idempotent-webhook-relay/internal/allowlist"
+	"github.com/petechu/idempotent-webhook-relay/internal/config"
+	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/listener"
+)
+
+// RunFromOutbox processes events by claiming them straight from the outbox
+// table, without a producer or broker, until c.Context is done. A claimed row
+// is marked pending and leased to this consumer for ConsumerClaimLease; rows
+// whose lease expires, e.g. because the consumer crashed, are claimed again.
+// Failed events are released to be claimed again when requeue is set and
+// dead-lettered otherwise.
+func (c Consumer) RunFromOutbox(cfg *config.Config, requeue bool) {
+	var (
+		jobs  = make(chan db.Outbox)
+		wg    sync.WaitGroup
+		id    = consumerID(cfg)
+		allow = cfg.EventTypeAllowlist()
+	)
+
+	for range cfg.ConsumerWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for event := range jobs {
+				err := c.process(event)
+				if err == nil {
+					continue
+				}
+				c.processFailed(event.ID, err)
+				if requeue {
+					if releaseErr := c.DB.ReleaseOutboxClaim(c.Context, event.ID); releaseErr != nil {
+						log.Printf("Failed to release claim on event %s: %v", event.EventID, releaseErr)
+					}
+				} else {
+					c.recordDeadLetter(event, err)
+				}
+			}
+		}()
+	}
+
+	// Claim no more rows than there are workers so that leases do not run
+	// down while rows wait to be picked up.
+	batchSize := int32(cfg.ConsumerWorkers)
+	listener.Start(c.Context, cfg.DatabaseURL(), cfg.ConsumerPollInterval, func() {
+		for {
+			claimed := c.claimBatch(id, batchSize, cfg.ConsumerClaimLease, allow, jobs)
+			if claimed < int(batchSize) {
+				return
+			}
+		}
+	})
+
+	close(jobs)
+	wg.Wait()
+}
+
+func (c Consumer) claimBatch(id string, batchSize int32, lease time.Duration, allow *allowlist.Allowlist, jobs chan<- db.Outbox) int {
+	ctx, cancel := context.WithTimeout(c.Context, 5*time.Second)
+	defer cancel()
+
+	events, err := c.DB.ClaimOutboxEvents(ctx, db.ClaimOutboxEventsParams{
+		ClaimedBy: pgtype.Text{
+			String: id,
+			Valid:  true,
+		},
+		ClaimExpiresAt: pgtype.Timestamptz{
+			Time:  time.Now().Add(lease),
+			Valid: true,
+		},
+		BatchSize: batchSize,
+	})
+	if err != nil {
+		fmt.Printf(" [!] Error claiming events: %s\n", err)
+		return 0
+	}
+
+	for _, event := range events {
+		if !allow.Allows(event.Provider, event.Type) {
+			c.ignore(event.ID)
+			continue
+		}
+		select {
+		case jobs <- event:
+		case <-c.Context.Done():
+			return 0
+		}
+	}
+	return len(events)
+}
+
+func (c Consumer) ignore(eventID int32) {
+	if err := c.DB.UpdateOutboxEvent(c.Context, db.UpdateOutboxEventParams{
+		ID: eventID,
+		Status: pgtype.Text{
+			Valid:  true,
+			String: "ignored",
+		},
+	}); err != nil {
+		log.Printf("Failed to mark event %d as ignored: %v", eventID, err)
+	}
+}
+
+func consumerID(cfg *config.Config) string {
+	if cfg.ConsumerID != "" {
+		return cfg.ConsumerID
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "consumer"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox
+    ADD COLUMN endpoint TEXT,
+    ADD COLUMN account TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE outbox
+    DROP COLUMN IF EXISTS endpoint,
+    DROP COLUMN IF EXISTS account;
+-- +goose StatementEnd
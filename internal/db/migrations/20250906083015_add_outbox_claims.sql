@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox
+    ADD COLUMN claimed_by TEXT,
+    ADD COLUMN claim_expires_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE outbox
+    DROP COLUMN IF EXISTS claimed_by,
+    DROP COLUMN IF EXISTS claim_expires_at;
+-- +goose StatementEnd
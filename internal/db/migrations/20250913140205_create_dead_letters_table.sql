@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE dead_letters (
+    id SERIAL PRIMARY KEY,
+    outbox_id INTEGER NOT NULL REFERENCES outbox(id),
+    event_id TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL,
+    errors TEXT[] NOT NULL,
+    last_error TEXT,
+    redriven_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX dead_letters_outbox_id_idx ON dead_letters (outbox_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS dead_letters;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox
+    ADD COLUMN response_status INTEGER,
+    ADD COLUMN response_body TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE outbox
+    DROP COLUMN IF EXISTS response_status,
+    DROP COLUMN IF EXISTS response_body;
+-- +goose StatementEnd
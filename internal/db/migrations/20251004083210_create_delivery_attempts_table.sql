@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE delivery_attempts (
+    id SERIAL PRIMARY KEY,
+    outbox_id INTEGER NOT NULL REFERENCES outbox(id),
+    subscription_id INTEGER REFERENCES subscriptions(id) ON DELETE SET NULL,
+    url TEXT NOT NULL,
+    attempt INTEGER NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    latency_ms INTEGER NOT NULL,
+    outcome TEXT NOT NULL,
+    http_status INTEGER,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX delivery_attempts_outbox_id_idx ON delivery_attempts (outbox_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS delivery_attempts;
+-- +goose StatementEnd
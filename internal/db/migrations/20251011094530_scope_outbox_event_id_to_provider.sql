@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox
+    DROP CONSTRAINT IF EXISTS outbox_event_id_key,
+    ADD CONSTRAINT outbox_provider_event_id_key UNIQUE (provider, event_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE outbox
+    DROP CONSTRAINT IF EXISTS outbox_provider_event_id_key,
+    ADD CONSTRAINT outbox_event_id_key UNIQUE (event_id);
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"path/filepath"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
+
+// Up applies every pending embedded migration.
+func Up(ctx context.Context, dbURL string) error {
+	db, err := sql.Open("pgx", dbURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, Embed)
+	if err != nil {
+		return err
+	}
+
+	sources := provider.ListSources()
+	for _, s := range sources {
+		log.Printf("%-3s %-2v %v\n", s.Type, s.Version, filepath.Base(s.Path))
+	}
+
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		log.Printf("%-3s %-2v done: %v\n", r.Source.Type, r.Source.Version, r.Duration)
+	}
+
+	return nil
+}
@@ -8,16 +8,74 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type DeadLetter struct {
+	ID         int32
+	OutboxID   int32
+	EventID    string
+	Payload    []byte
+	Attempts   int32
+	Errors     []string
+	LastError  pgtype.Text
+	RedrivenAt pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+}
+
+type DeliveryAttempt struct {
+	ID             int32
+	OutboxID       int32
+	SubscriptionID pgtype.Int4
+	Url            string
+	Attempt        int32
+	StartedAt      pgtype.Timestamptz
+	FinishedAt     pgtype.Timestamptz
+	LatencyMs      int32
+	Outcome        string
+	HttpStatus     pgtype.Int4
+	Error          pgtype.Text
+	CreatedAt      pgtype.Timestamptz
+}
+
 type Outbox struct {
-	ID            int32
-	EventID       string
-	Type          string
-	Payload       []byte
-	Status        pgtype.Text
-	Provider      string
-	RetryCount    int32
-	LastError     pgtype.Text
-	LastAttemptAt pgtype.Timestamptz
-	CreatedAt     pgtype.Timestamptz
-	UpdatedAt     pgtype.Timestamptz
+	ID             int32
+	EventID        string
+	Type           string
+	Payload        []byte
+	Status         pgtype.Text
+	Provider       string
+	RetryCount     int32
+	LastError      pgtype.Text
+	LastAttemptAt  pgtype.Timestamptz
+	CreatedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+	Endpoint       pgtype.Text
+	Account        pgtype.Text
+	ClaimedBy      pgtype.Text
+	ClaimExpiresAt pgtype.Timestamptz
+	ResponseStatus pgtype.Int4
+	ResponseBody   pgtype.Text
+}
+
+type Subscription struct {
+	ID         int32
+	Url        string
+	Provider   pgtype.Text
+	EventTypes []string
+	Enabled    bool
+	Secret     pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+}
+
+type SubscriptionDelivery struct {
+	ID             int32
+	OutboxID       int32
+	SubscriptionID int32
+	Status         string
+	Attempts       int32
+	ResponseStatus pgtype.Int4
+	ResponseBody   pgtype.Text
+	LastError      pgtype.Text
+	LastAttemptAt  pgtype.Timestamptz
+	DeliveredAt    pgtype.Timestamptz
+	CreatedAt      pgtype.Timestamptz
 }
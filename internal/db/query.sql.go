@@ -11,13 +11,234 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimOutboxEvents = `-- name: ClaimOutboxEvents :many
+UPDATE outbox
+SET
+  status = 'pending',
+  claimed_by = $1,
+  claim_expires_at = $2
+WHERE id IN (
+  SELECT id FROM outbox
+  WHERE COALESCE(status, '') NOT IN ('processed', 'ignored', 'dead_lettered')
+  AND (claim_expires_at IS NULL OR claim_expires_at < NOW())
+  ORDER BY id
+  LIMIT $3
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, event_id, type, payload, status, provider, retry_count, last_error, last_attempt_at, created_at, updated_at, endpoint, account, claimed_by, claim_expires_at, response_status, response_body
+`
+
+type ClaimOutboxEventsParams struct {
+	ClaimedBy      pgtype.Text
+	ClaimExpiresAt pgtype.Timestamptz
+	BatchSize      int32
+}
+
+func (q *Queries) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, claimOutboxEvents,
+		arg.ClaimedBy,
+		arg.ClaimExpiresAt,
+		arg.BatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outbox
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Provider,
+			&i.RetryCount,
+			&i.LastError,
+			&i.LastAttemptAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Endpoint,
+			&i.Account,
+			&i.ClaimedBy,
+			&i.ClaimExpiresAt,
+			&i.ResponseStatus,
+			&i.ResponseBody,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimUnprocessedEvents = `-- name: ClaimUnprocessedEvents :many
+UPDATE outbox
+SET
+  claimed_by = $1,
+  claim_expires_at = $2
+WHERE id IN (
+  SELECT id FROM outbox
+  WHERE COALESCE(status, '') NOT IN ('pending', 'processed', 'ignored', 'dead_lettered')
+  AND (claim_expires_at IS NULL OR claim_expires_at < NOW())
+  ORDER BY id
+  LIMIT $3
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, event_id, type, payload, status, provider, retry_count, last_error, last_attempt_at, created_at, updated_at, endpoint, account, claimed_by, claim_expires_at, response_status, response_body
+`
+
+type ClaimUnprocessedEventsParams struct {
+	ClaimedBy      pgtype.Text
+	ClaimExpiresAt pgtype.Timestamptz
+	BatchSize      int32
+}
+
+func (q *Queries) ClaimUnprocessedEvents(ctx context.Context, arg ClaimUnprocessedEventsParams) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, claimUnprocessedEvents,
+		arg.ClaimedBy,
+		arg.ClaimExpiresAt,
+		arg.BatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outbox
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Provider,
+			&i.RetryCount,
+			&i.LastError,
+			&i.LastAttemptAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Endpoint,
+			&i.Account,
+			&i.ClaimedBy,
+			&i.ClaimExpiresAt,
+			&i.ResponseStatus,
+			&i.ResponseBody,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createSubscription = `-- name: CreateSubscription :one
+INSERT INTO subscriptions (url, provider, event_types, enabled, secret)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, url, provider, event_types, enabled, secret, created_at, updated_at
+`
+
+type CreateSubscriptionParams struct {
+	Url        string
+	Provider   pgtype.Text
+	EventTypes []string
+	Enabled    bool
+	Secret     pgtype.Text
+}
+
+func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscriptionParams) (Subscription, error) {
+	row := q.db.QueryRow(ctx, createSubscription,
+		arg.Url,
+		arg.Provider,
+		arg.EventTypes,
+		arg.Enabled,
+		arg.Secret,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Provider,
+		&i.EventTypes,
+		&i.Enabled,
+		&i.Secret,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteSubscription = `-- name: DeleteSubscription :execrows
+DELETE FROM subscriptions
+WHERE id = $1
+`
+
+func (q *Queries) DeleteSubscription(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSubscription, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const ensureSubscriptionDelivery = `-- name: EnsureSubscriptionDelivery :exec
+INSERT INTO subscription_deliveries (outbox_id, subscription_id)
+VALUES ($1, $2)
+ON CONFLICT (outbox_id, subscription_id) DO NOTHING
+`
+
+type EnsureSubscriptionDeliveryParams struct {
+	OutboxID       int32
+	SubscriptionID int32
+}
+
+func (q *Queries) EnsureSubscriptionDelivery(ctx context.Context, arg EnsureSubscriptionDeliveryParams) error {
+	_, err := q.db.Exec(ctx, ensureSubscriptionDelivery, arg.OutboxID, arg.SubscriptionID)
+	return err
+}
+
+const getDeadLetter = `-- name: GetDeadLetter :one
+SELECT id, outbox_id, event_id, payload, attempts, errors, last_error, redriven_at, created_at FROM dead_letters
+WHERE id = $1
+`
+
+func (q *Queries) GetDeadLetter(ctx context.Context, id int32) (DeadLetter, error) {
+	row := q.db.QueryRow(ctx, getDeadLetter, id)
+	var i DeadLetter
+	err := row.Scan(
+		&i.ID,
+		&i.OutboxID,
+		&i.EventID,
+		&i.Payload,
+		&i.Attempts,
+		&i.Errors,
+		&i.LastError,
+		&i.RedrivenAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getOutBoxEvent = `-- name: GetOutBoxEvent :one
-SELECT id, event_id, type, payload, status, provider, retry_count, last_error, last_attempt_at, created_at, updated_at FROM outbox
-WHERE event_id = $1
+SELECT id, event_id, type, payload, status, provider, retry_count, last_error, last_attempt_at, created_at, updated_at, endpoint, account, claimed_by, claim_expires_at, response_status, response_body FROM outbox
+WHERE provider = $1 AND event_id = $2
 `
 
-func (q *Queries) GetOutBoxEvent(ctx context.Context, eventID string) (Outbox, error) {
-	row := q.db.QueryRow(ctx, getOutBoxEvent, eventID)
+type GetOutBoxEventParams struct {
+	Provider string
+	EventID  string
+}
+
+func (q *Queries) GetOutBoxEvent(ctx context.Context, arg GetOutBoxEventParams) (Outbox, error) {
+	row := q.db.QueryRow(ctx, getOutBoxEvent, arg.Provider, arg.EventID)
 	var i Outbox
 	err := row.Scan(
 		&i.ID,
@@ -31,12 +252,147 @@ func (q *Queries) GetOutBoxEvent(ctx context.Context, eventID string) (Outbox, e
 		&i.LastAttemptAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Endpoint,
+		&i.Account,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.ResponseStatus,
+		&i.ResponseBody,
+	)
+	return i, err
+}
+
+const getSubscription = `-- name: GetSubscription :one
+SELECT id, url, provider, event_types, enabled, secret, created_at, updated_at FROM subscriptions
+WHERE id = $1
+`
+
+func (q *Queries) GetSubscription(ctx context.Context, id int32) (Subscription, error) {
+	row := q.db.QueryRow(ctx, getSubscription, id)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Provider,
+		&i.EventTypes,
+		&i.Enabled,
+		&i.Secret,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getSubscriptionDelivery = `-- name: GetSubscriptionDelivery :one
+SELECT id, outbox_id, subscription_id, status, attempts, response_status, response_body, last_error, last_attempt_at, delivered_at, created_at FROM subscription_deliveries
+WHERE outbox_id = $1 AND subscription_id = $2
+`
+
+type GetSubscriptionDeliveryParams struct {
+	OutboxID       int32
+	SubscriptionID int32
+}
+
+func (q *Queries) GetSubscriptionDelivery(ctx context.Context, arg GetSubscriptionDeliveryParams) (SubscriptionDelivery, error) {
+	row := q.db.QueryRow(ctx, getSubscriptionDelivery, arg.OutboxID, arg.SubscriptionID)
+	var i SubscriptionDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.OutboxID,
+		&i.SubscriptionID,
+		&i.Status,
+		&i.Attempts,
+		&i.ResponseStatus,
+		&i.ResponseBody,
+		&i.LastError,
+		&i.LastAttemptAt,
+		&i.DeliveredAt,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
+const incrementOutboxRetryCount = `-- name: IncrementOutboxRetryCount :one
+UPDATE outbox
+SET retry_count = retry_count + 1
+WHERE id = $1
+RETURNING retry_count
+`
+
+func (q *Queries) IncrementOutboxRetryCount(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, incrementOutboxRetryCount, id)
+	var retry_count int32
+	err := row.Scan(&retry_count)
+	return retry_count, err
+}
+
+const insertDeadLetter = `-- name: InsertDeadLetter :one
+INSERT INTO dead_letters (outbox_id, event_id, payload, attempts, errors, last_error)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id
+`
+
+type InsertDeadLetterParams struct {
+	OutboxID  int32
+	EventID   string
+	Payload   []byte
+	Attempts  int32
+	Errors    []string
+	LastError pgtype.Text
+}
+
+func (q *Queries) InsertDeadLetter(ctx context.Context, arg InsertDeadLetterParams) (int32, error) {
+	row := q.db.QueryRow(ctx, insertDeadLetter,
+		arg.OutboxID,
+		arg.EventID,
+		arg.Payload,
+		arg.Attempts,
+		arg.Errors,
+		arg.LastError,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const insertDeliveryAttempt = `-- name: InsertDeliveryAttempt :exec
+INSERT INTO delivery_attempts (
+  outbox_id, subscription_id, url, attempt, started_at, finished_at, latency_ms, outcome, http_status, error
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+`
+
+type InsertDeliveryAttemptParams struct {
+	OutboxID       int32
+	SubscriptionID pgtype.Int4
+	Url            string
+	Attempt        int32
+	StartedAt      pgtype.Timestamptz
+	FinishedAt     pgtype.Timestamptz
+	LatencyMs      int32
+	Outcome        string
+	HttpStatus     pgtype.Int4
+	Error          pgtype.Text
+}
+
+func (q *Queries) InsertDeliveryAttempt(ctx context.Context, arg InsertDeliveryAttemptParams) error {
+	_, err := q.db.Exec(ctx, insertDeliveryAttempt,
+		arg.OutboxID,
+		arg.SubscriptionID,
+		arg.Url,
+		arg.Attempt,
+		arg.StartedAt,
+		arg.FinishedAt,
+		arg.LatencyMs,
+		arg.Outcome,
+		arg.HttpStatus,
+		arg.Error,
+	)
+	return err
+}
+
 const insertOutboxEvent = `-- name: InsertOutboxEvent :one
-INSERT INTO outbox (event_id, type, payload, provider) VALUES ($1, $2, $3, $4)
+INSERT INTO outbox (event_id, type, payload, provider, endpoint, account) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (provider, event_id) DO NOTHING
 RETURNING id
 `
 
@@ -45,6 +401,8 @@ type InsertOutboxEventParams struct {
 	Type     string
 	Payload  []byte
 	Provider string
+	Endpoint pgtype.Text
+	Account  pgtype.Text
 }
 
 func (q *Queries) InsertOutboxEvent(ctx context.Context, arg InsertOutboxEventParams) (int32, error) {
@@ -53,14 +411,91 @@ func (q *Queries) InsertOutboxEvent(ctx context.Context, arg InsertOutboxEventPa
 		arg.Type,
 		arg.Payload,
 		arg.Provider,
+		arg.Endpoint,
+		arg.Account,
 	)
 	var id int32
 	err := row.Scan(&id)
 	return id, err
 }
 
+const listDeadLetters = `-- name: ListDeadLetters :many
+SELECT id, outbox_id, event_id, payload, attempts, errors, last_error, redriven_at, created_at FROM dead_letters
+WHERE redriven_at IS NULL
+ORDER BY id
+`
+
+func (q *Queries) ListDeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	rows, err := q.db.Query(ctx, listDeadLetters)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeadLetter
+	for rows.Next() {
+		var i DeadLetter
+		if err := rows.Scan(
+			&i.ID,
+			&i.OutboxID,
+			&i.EventID,
+			&i.Payload,
+			&i.Attempts,
+			&i.Errors,
+			&i.LastError,
+			&i.RedrivenAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeliveryAttempts = `-- name: ListDeliveryAttempts :many
+SELECT id, outbox_id, subscription_id, url, attempt, started_at, finished_at, latency_ms, outcome, http_status, error, created_at FROM delivery_attempts
+WHERE outbox_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListDeliveryAttempts(ctx context.Context, outboxID int32) ([]DeliveryAttempt, error) {
+	rows, err := q.db.Query(ctx, listDeliveryAttempts, outboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeliveryAttempt
+	for rows.Next() {
+		var i DeliveryAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.OutboxID,
+			&i.SubscriptionID,
+			&i.Url,
+			&i.Attempt,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.LatencyMs,
+			&i.Outcome,
+			&i.HttpStatus,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEvents = `-- name: ListEvents :many
-SELECT id, event_id, type, payload, status, provider, retry_count, last_error, last_attempt_at, created_at, updated_at FROM outbox
+SELECT id, event_id, type, payload, status, provider, retry_count, last_error, last_attempt_at, created_at, updated_at, endpoint, account, claimed_by, claim_expires_at, response_status, response_body FROM outbox
 `
 
 func (q *Queries) ListEvents(ctx context.Context) ([]Outbox, error) {
@@ -84,6 +519,12 @@ func (q *Queries) ListEvents(ctx context.Context) ([]Outbox, error) {
 			&i.LastAttemptAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Endpoint,
+			&i.Account,
+			&i.ClaimedBy,
+			&i.ClaimExpiresAt,
+			&i.ResponseStatus,
+			&i.ResponseBody,
 		); err != nil {
 			return nil, err
 		}
@@ -96,7 +537,7 @@ func (q *Queries) ListEvents(ctx context.Context) ([]Outbox, error) {
 }
 
 const listFailedEvents = `-- name: ListFailedEvents :many
-SELECT id, event_id, type, payload, status, provider, retry_count, last_error, last_attempt_at, created_at, updated_at FROM outbox
+SELECT id, event_id, type, payload, status, provider, retry_count, last_error, last_attempt_at, created_at, updated_at, endpoint, account, claimed_by, claim_expires_at, response_status, response_body FROM outbox
 WHERE status = 'failed'
 `
 
@@ -121,6 +562,120 @@ func (q *Queries) ListFailedEvents(ctx context.Context) ([]Outbox, error) {
 			&i.LastAttemptAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Endpoint,
+			&i.Account,
+			&i.ClaimedBy,
+			&i.ClaimExpiresAt,
+			&i.ResponseStatus,
+			&i.ResponseBody,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMatchingSubscriptions = `-- name: ListMatchingSubscriptions :many
+SELECT id, url, provider, event_types, enabled, secret, created_at, updated_at FROM subscriptions
+WHERE enabled
+AND (provider IS NULL OR provider = $1::text)
+ORDER BY id
+`
+
+func (q *Queries) ListMatchingSubscriptions(ctx context.Context, provider string) ([]Subscription, error) {
+	rows, err := q.db.Query(ctx, listMatchingSubscriptions, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subscription
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Provider,
+			&i.EventTypes,
+			&i.Enabled,
+			&i.Secret,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptionDeliveries = `-- name: ListSubscriptionDeliveries :many
+SELECT id, outbox_id, subscription_id, status, attempts, response_status, response_body, last_error, last_attempt_at, delivered_at, created_at FROM subscription_deliveries
+WHERE outbox_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListSubscriptionDeliveries(ctx context.Context, outboxID int32) ([]SubscriptionDelivery, error) {
+	rows, err := q.db.Query(ctx, listSubscriptionDeliveries, outboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SubscriptionDelivery
+	for rows.Next() {
+		var i SubscriptionDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.OutboxID,
+			&i.SubscriptionID,
+			&i.Status,
+			&i.Attempts,
+			&i.ResponseStatus,
+			&i.ResponseBody,
+			&i.LastError,
+			&i.LastAttemptAt,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptions = `-- name: ListSubscriptions :many
+SELECT id, url, provider, event_types, enabled, secret, created_at, updated_at FROM subscriptions
+ORDER BY id
+`
+
+func (q *Queries) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	rows, err := q.db.Query(ctx, listSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subscription
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Provider,
+			&i.EventTypes,
+			&i.Enabled,
+			&i.Secret,
+			&i.CreatedAt,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -133,8 +688,8 @@ func (q *Queries) ListFailedEvents(ctx context.Context) ([]Outbox, error) {
 }
 
 const listUnprocessedEvents = `-- name: ListUnprocessedEvents :many
-SELECT id, event_id, type, payload, status, provider, retry_count, last_error, last_attempt_at, created_at, updated_at FROM outbox
-WHERE COALESCE(status, '') NOT IN ('pending', 'processed')
+SELECT id, event_id, type, payload, status, provider, retry_count, last_error, last_attempt_at, created_at, updated_at, endpoint, account, claimed_by, claim_expires_at, response_status, response_body FROM outbox
+WHERE COALESCE(status, '') NOT IN ('pending', 'processed', 'ignored', 'dead_lettered')
 AND type = ANY($1::varchar[])
 `
 
@@ -159,6 +714,12 @@ func (q *Queries) ListUnprocessedEvents(ctx context.Context, dollar_1 []string)
 			&i.LastAttemptAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Endpoint,
+			&i.Account,
+			&i.ClaimedBy,
+			&i.ClaimExpiresAt,
+			&i.ResponseStatus,
+			&i.ResponseBody,
 		); err != nil {
 			return nil, err
 		}
@@ -170,6 +731,118 @@ func (q *Queries) ListUnprocessedEvents(ctx context.Context, dollar_1 []string)
 	return items, nil
 }
 
+const markDeadLetterRedriven = `-- name: MarkDeadLetterRedriven :execrows
+UPDATE dead_letters
+SET redriven_at = NOW()
+WHERE id = $1 AND redriven_at IS NULL
+`
+
+func (q *Queries) MarkDeadLetterRedriven(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, markDeadLetterRedriven, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markOutboxDeadLettered = `-- name: MarkOutboxDeadLettered :exec
+UPDATE outbox
+SET
+  status = 'dead_lettered',
+  claim_expires_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxDeadLettered(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, markOutboxDeadLettered, id)
+	return err
+}
+
+const markOutboxPublished = `-- name: MarkOutboxPublished :exec
+UPDATE outbox
+SET
+  status = 'pending',
+  last_error = NULL,
+  last_attempt_at = NULL
+WHERE id = $1
+AND claimed_by = $2
+AND COALESCE(status, '') NOT IN ('processed', 'process_failed', 'dead_lettered')
+`
+
+type MarkOutboxPublishedParams struct {
+	ID        int32
+	ClaimedBy pgtype.Text
+}
+
+func (q *Queries) MarkOutboxPublished(ctx context.Context, arg MarkOutboxPublishedParams) error {
+	_, err := q.db.Exec(ctx, markOutboxPublished, arg.ID, arg.ClaimedBy)
+	return err
+}
+
+const recordOutboxResponse = `-- name: RecordOutboxResponse :exec
+UPDATE outbox
+SET
+  response_status = $2,
+  response_body = $3
+WHERE id = $1
+`
+
+type RecordOutboxResponseParams struct {
+	ID             int32
+	ResponseStatus pgtype.Int4
+	ResponseBody   pgtype.Text
+}
+
+func (q *Queries) RecordOutboxResponse(ctx context.Context, arg RecordOutboxResponseParams) error {
+	_, err := q.db.Exec(ctx, recordOutboxResponse, arg.ID, arg.ResponseStatus, arg.ResponseBody)
+	return err
+}
+
+const releaseOutboxClaim = `-- name: ReleaseOutboxClaim :exec
+UPDATE outbox
+SET claim_expires_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) ReleaseOutboxClaim(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, releaseOutboxClaim, id)
+	return err
+}
+
+const resetOutboxEvent = `-- name: ResetOutboxEvent :execrows
+UPDATE outbox
+SET
+  status = NULL,
+  claimed_by = NULL,
+  claim_expires_at = NULL
+WHERE id = $1 AND status = 'dead_lettered'
+`
+
+func (q *Queries) ResetOutboxEvent(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, resetOutboxEvent, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const resetPendingOutboxEvents = `-- name: ResetPendingOutboxEvents :execrows
+UPDATE outbox
+SET
+  status = NULL,
+  claimed_by = NULL,
+  claim_expires_at = NULL
+WHERE status = 'pending'
+`
+
+func (q *Queries) ResetPendingOutboxEvents(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, resetPendingOutboxEvents)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateOutboxEvent = `-- name: UpdateOutboxEvent :exec
 UPDATE outbox 
 SET 
@@ -195,3 +868,82 @@ func (q *Queries) UpdateOutboxEvent(ctx context.Context, arg UpdateOutboxEventPa
 	)
 	return err
 }
+
+const updateSubscription = `-- name: UpdateSubscription :one
+UPDATE subscriptions
+SET
+  url = $2,
+  provider = $3,
+  event_types = $4,
+  enabled = $5,
+  secret = $6,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING id, url, provider, event_types, enabled, secret, created_at, updated_at
+`
+
+type UpdateSubscriptionParams struct {
+	ID         int32
+	Url        string
+	Provider   pgtype.Text
+	EventTypes []string
+	Enabled    bool
+	Secret     pgtype.Text
+}
+
+func (q *Queries) UpdateSubscription(ctx context.Context, arg UpdateSubscriptionParams) (Subscription, error) {
+	row := q.db.QueryRow(ctx, updateSubscription,
+		arg.ID,
+		arg.Url,
+		arg.Provider,
+		arg.EventTypes,
+		arg.Enabled,
+		arg.Secret,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Provider,
+		&i.EventTypes,
+		&i.Enabled,
+		&i.Secret,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateSubscriptionDelivery = `-- name: UpdateSubscriptionDelivery :exec
+UPDATE subscription_deliveries
+SET
+  status = $2,
+  attempts = attempts + 1,
+  response_status = $3,
+  response_body = $4,
+  last_error = $5,
+  last_attempt_at = NOW(),
+  delivered_at = $6
+WHERE id = $1
+`
+
+type UpdateSubscriptionDeliveryParams struct {
+	ID             int32
+	Status         string
+	ResponseStatus pgtype.Int4
+	ResponseBody   pgtype.Text
+	LastError      pgtype.Text
+	DeliveredAt    pgtype.Timestamptz
+}
+
+func (q *Queries) UpdateSubscriptionDelivery(ctx context.Context, arg UpdateSubscriptionDeliveryParams) error {
+	_, err := q.db.Exec(ctx, updateSubscriptionDelivery,
+		arg.ID,
+		arg.Status,
+		arg.ResponseStatus,
+		arg.ResponseBody,
+		arg.LastError,
+		arg.DeliveredAt,
+	)
+	return err
+}
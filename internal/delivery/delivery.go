@@ -0,0 +1,116 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/petechu/idempotent-webhook-relay/internal/config"
+	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/utils"
+)
+
+// Response is what a downstream endpoint answered, with the body truncated
+// to MaxResponseBytes.
+type Response struct {
+	StatusCode int
+	Body       string
+}
+
+// StatusError is returned for a non-2xx response.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s responded with status %d", e.URL, e.StatusCode)
+}
+
+// Deliverer POSTs outbox events to downstream endpoints.
+type Deliverer struct {
+	URLs                 []string
+	MaxResponseBytes     int
+	NonRetryableStatuses []int
+
+	client *http.Client
+}
+
+func NewDeliverer(cfg *config.Config) *Deliverer {
+	return &Deliverer{
+		URLs:                 cfg.DeliveryURLs,
+		MaxResponseBytes:     cfg.DeliveryMaxResponseBytes,
+		NonRetryableStatuses: cfg.DeliveryNonRetryableStatuses,
+		client: &http.Client{
+			Timeout: cfg.DeliveryTimeout,
+		},
+	}
+}
+
+// Post sends the event's payload to url, signed with secret when one is set.
+// A 2xx response is a success; any other status or a network error is
+// returned as an error, wrapped with utils.Permanent when the status is
+// configured as non-retryable. The response is returned whenever one was
+// received.
+func (d *Deliverer) Post(ctx context.Context, url, secret string, event db.Outbox) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(event.Payload))
+	if err != nil {
+		return nil, utils.Permanent(fmt.Errorf("failed to create request for %s: %w", url, err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// Event IDs are only unique per provider.
+	req.Header.Set("Idempotency-Key", event.Provider+":"+event.EventID)
+	req.Header.Set("X-Webhook-Event-Id", event.EventID)
+	req.Header.Set("X-Webhook-Event-Type", event.Type)
+	req.Header.Set("X-Webhook-Provider", event.Provider)
+	if secret != "" {
+		req.Header.Set("X-Webhook-Signature", "sha256="+sign(secret, event.Payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deliver to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(d.MaxResponseBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", url, err)
+	}
+	// Drain the rest so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	response := &Response{
+		StatusCode: resp.StatusCode,
+		Body:       sanitize(body),
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return response, nil
+	}
+
+	statusErr := &StatusError{URL: url, StatusCode: resp.StatusCode}
+	if slices.Contains(d.NonRetryableStatuses, resp.StatusCode) {
+		return response, utils.Permanent(statusErr)
+	}
+	return response, statusErr
+}
+
+// sign returns the hex HMAC-SHA256 of payload, which subscribers can verify
+// the same way as GitHub's X-Hub-Signature-256.
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sanitize makes a truncated body safe to store in a TEXT column.
+func sanitize(body []byte) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(string(body), ""), "\x00", "")
+}
@@ -0,0 +1,85 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/petechu/idempotent-webhook-relay/internal/config"
+	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/utils"
+)
+
+func TestPostHeaders(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	d := NewDeliverer(&config.Config{DeliveryTimeout: time.Second, DeliveryMaxResponseBytes: 1024})
+	event := db.Outbox{
+		EventID:  "evt_1",
+		Type:     "invoice.paid",
+		Provider: "stripe",
+		Payload:  []byte(`{"id":"evt_1"}`),
+	}
+	if _, err := d.Post(context.Background(), server.URL, "secret", event); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	want := map[string]string{
+		"Idempotency-Key":      "stripe:evt_1",
+		"X-Webhook-Event-Id":   "evt_1",
+		"X-Webhook-Event-Type": "invoice.paid",
+		"X-Webhook-Provider":   "stripe",
+		"X-Webhook-Signature":  "sha256=" + sign("secret", event.Payload),
+	}
+	for name, value := range want {
+		if got := header.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestPostStatuses(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{name: "success", status: http.StatusAccepted},
+		{name: "retryable", status: http.StatusServiceUnavailable, wantErr: true},
+		{name: "non-retryable", status: http.StatusUnprocessableEntity, wantErr: true, wantPermanent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte("response body"))
+			}))
+			defer server.Close()
+
+			d := NewDeliverer(&config.Config{
+				DeliveryTimeout:              time.Second,
+				DeliveryMaxResponseBytes:     8,
+				DeliveryNonRetryableStatuses: []int{http.StatusUnprocessableEntity},
+			})
+			resp, err := d.Post(context.Background(), server.URL, "", db.Outbox{EventID: "evt_1", Provider: "stripe"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Post() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var permanent *utils.PermanentError
+			if errors.As(err, &permanent) != tt.wantPermanent {
+				t.Fatalf("Post() error = %v, want permanent %v", err, tt.wantPermanent)
+			}
+			if resp == nil || resp.StatusCode != tt.status || resp.Body != "response" {
+				t.Fatalf("Post() response = %+v", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/petechu/idempotent-webhook-relay/internal/logic"
+	"github.com/petechu/idempotent-webhook-relay/internal/svc"
+)
+
+func listDeadLettersHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := logic.NewDeadLetterLogic(c.Request.Context(), svcCtx)
+		items, err := l.ListDeadLetters()
+		if err != nil {
+			fmt.Println("Error listing dead letters:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Failed to list dead letters",
+			})
+			return
+		}
+
+		deadLetters := make([]gin.H, 0, len(items))
+		for _, item := range items {
+			deadLetters = append(deadLetters, gin.H{
+				"id":         item.ID,
+				"outbox_id":  item.OutboxID,
+				"event_id":   item.EventID,
+				"payload":    json.RawMessage(item.Payload),
+				"attempts":   item.Attempts,
+				"errors":     item.Errors,
+				"last_error": item.LastError.String,
+				"created_at": item.CreatedAt.Time,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"dead_letters": deadLetters,
+		})
+	}
+}
+
+func redriveDeadLetterHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid dead letter id",
+			})
+			return
+		}
+
+		l := logic.NewDeadLetterLogic(c.Request.Context(), svcCtx)
+		err = l.Redrive(int32(id))
+		if errors.Is(err, logic.ErrDeadLetterNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Dead letter not found",
+			})
+			return
+		}
+		if errors.Is(err, logic.ErrDeadLetterRedriven) {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Dead letter was already redriven",
+			})
+			return
+		}
+		if err != nil {
+			fmt.Println("Error redriving dead letter:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Failed to redrive dead letter",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Dead letter redriven",
+		})
+	}
+}
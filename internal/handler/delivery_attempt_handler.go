@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/petechu/idempotent-webhook-relay/internal/logic"
+	"github.com/petechu/idempotent-webhook-relay/internal/svc"
+)
+
+func listEventAttemptsHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := logic.NewDeliveryAttemptLogic(c.Request.Context(), svcCtx)
+		items, err := l.ListAttempts(c.Param("provider"), c.Param("event_id"))
+		if errors.Is(err, logic.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Event not found",
+			})
+			return
+		}
+		if err != nil {
+			fmt.Println("Error listing delivery attempts:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Failed to list delivery attempts",
+			})
+			return
+		}
+
+		attempts := make([]gin.H, 0, len(items))
+		for _, item := range items {
+			attempts = append(attempts, gin.H{
+				"attempt":         item.Attempt,
+				"subscription_id": item.SubscriptionID.Int32,
+				"url":             item.Url,
+				"started_at":      item.StartedAt.Time,
+				"finished_at":     item.FinishedAt.Time,
+				"latency_ms":      item.LatencyMs,
+				"outcome":         item.Outcome,
+				"http_status":     item.HttpStatus.Int32,
+				"error":           item.Error.String,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"attempts": attempts,
+		})
+	}
+}
@@ -1,12 +1,36 @@
 package handler
 
 import (
+	"context"
+	"net/http"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/petechu/idempotent-webhook-relay/internal/queue"
 	"github.com/petechu/idempotent-webhook-relay/internal/svc"
 )
 
+// connectionState is implemented by brokers that supervise a connection.
+type connectionState interface {
+	State() queue.State
+	IsConnected() bool
+}
+
 func healthCheckHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+
+		if err := svcCtx.DB.Ping(ctx); err != nil {
+			c.String(http.StatusServiceUnavailable, "database unavailable")
+			return
+		}
+
+		if broker, ok := svcCtx.Broker.(connectionState); ok && !broker.IsConnected() {
+			c.String(http.StatusServiceUnavailable, "broker "+broker.State().String())
+			return
+		}
+
 		c.String(200, "OK")
 	}
 }
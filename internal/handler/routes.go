@@ -9,5 +9,17 @@ func RegisterRoutes(r *gin.Engine, svcCtx *svc.ServiceContext) {
 	r.Use(CORS())
 
 	r.GET("/healthz", healthCheckHandler(svcCtx))
-	r.POST("/stripe/webhook", stripeWebhookHandler(svcCtx))
+	r.POST("/:provider/webhook", webhookHandler(svcCtx))
+	r.POST("/:provider/:endpoint/webhook", webhookHandler(svcCtx))
+
+	admin := r.Group("/admin", AdminAuth(svcCtx.Config.AdminToken))
+	admin.GET("/dead-letters", listDeadLettersHandler(svcCtx))
+	admin.POST("/dead-letters/:id/redrive", redriveDeadLetterHandler(svcCtx))
+	admin.GET("/subscriptions", listSubscriptionsHandler(svcCtx))
+	admin.POST("/subscriptions", createSubscriptionHandler(svcCtx))
+	admin.GET("/subscriptions/:id", getSubscriptionHandler(svcCtx))
+	admin.PUT("/subscriptions/:id", updateSubscriptionHandler(svcCtx))
+	admin.DELETE("/subscriptions/:id", deleteSubscriptionHandler(svcCtx))
+	admin.GET("/events/:provider/:event_id/deliveries", listEventDeliveriesHandler(svcCtx))
+	admin.GET("/events/:provider/:event_id/attempts", listEventAttemptsHandler(svcCtx))
 }
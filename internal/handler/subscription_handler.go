@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/logic"
+	"github.com/petechu/idempotent-webhook-relay/internal/svc"
+)
+
+func listSubscriptionsHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := logic.NewSubscriptionLogic(c.Request.Context(), svcCtx)
+		items, err := l.ListSubscriptions()
+		if err != nil {
+			fmt.Println("Error listing subscriptions:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Failed to list subscriptions",
+			})
+			return
+		}
+
+		subscriptions := make([]gin.H, 0, len(items))
+		for _, item := range items {
+			subscriptions = append(subscriptions, subscriptionJSON(item))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"subscriptions": subscriptions,
+		})
+	}
+}
+
+func getSubscriptionHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := subscriptionID(c)
+		if !ok {
+			return
+		}
+
+		l := logic.NewSubscriptionLogic(c.Request.Context(), svcCtx)
+		item, err := l.GetSubscription(id)
+		if err != nil {
+			subscriptionError(c, "Failed to get subscription", err)
+			return
+		}
+
+		c.JSON(http.StatusOK, subscriptionJSON(item))
+	}
+}
+
+func createSubscriptionHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req logic.SubscriptionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid request body",
+			})
+			return
+		}
+
+		l := logic.NewSubscriptionLogic(c.Request.Context(), svcCtx)
+		item, err := l.CreateSubscription(req)
+		if err != nil {
+			subscriptionError(c, "Failed to create subscription", err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, subscriptionJSON(item))
+	}
+}
+
+func updateSubscriptionHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := subscriptionID(c)
+		if !ok {
+			return
+		}
+
+		var req logic.SubscriptionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid request body",
+			})
+			return
+		}
+
+		l := logic.NewSubscriptionLogic(c.Request.Context(), svcCtx)
+		item, err := l.UpdateSubscription(id, req)
+		if err != nil {
+			subscriptionError(c, "Failed to update subscription", err)
+			return
+		}
+
+		c.JSON(http.StatusOK, subscriptionJSON(item))
+	}
+}
+
+func deleteSubscriptionHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := subscriptionID(c)
+		if !ok {
+			return
+		}
+
+		l := logic.NewSubscriptionLogic(c.Request.Context(), svcCtx)
+		if err := l.DeleteSubscription(id); err != nil {
+			subscriptionError(c, "Failed to delete subscription", err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Subscription deleted",
+		})
+	}
+}
+
+func listEventDeliveriesHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := logic.NewSubscriptionLogic(c.Request.Context(), svcCtx)
+		items, err := l.ListDeliveries(c.Param("provider"), c.Param("event_id"))
+		if errors.Is(err, logic.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Event not found",
+			})
+			return
+		}
+		if err != nil {
+			fmt.Println("Error listing deliveries:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Failed to list deliveries",
+			})
+			return
+		}
+
+		deliveries := make([]gin.H, 0, len(items))
+		for _, item := range items {
+			deliveries = append(deliveries, gin.H{
+				"subscription_id": item.SubscriptionID,
+				"status":          item.Status,
+				"attempts":        item.Attempts,
+				"response_status": item.ResponseStatus.Int32,
+				"response_body":   item.ResponseBody.String,
+				"last_error":      item.LastError.String,
+				"last_attempt_at": item.LastAttemptAt.Time,
+				"delivered_at":    item.DeliveredAt.Time,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"deliveries": deliveries,
+		})
+	}
+}
+
+func subscriptionID(c *gin.Context) (int32, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid subscription id",
+		})
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func subscriptionError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, logic.ErrSubscriptionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Subscription not found",
+		})
+	case errors.Is(err, logic.ErrInvalidSubscription):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+	default:
+		fmt.Println("Error handling subscription:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": message,
+		})
+	}
+}
+
+// subscriptionJSON never exposes the signing secret, only whether one is set.
+func subscriptionJSON(item db.Subscription) gin.H {
+	return gin.H{
+		"id":          item.ID,
+		"url":         item.Url,
+		"provider":    item.Provider.String,
+		"event_types": item.EventTypes,
+		"enabled":     item.Enabled,
+		"has_secret":  item.Secret.Valid,
+		"created_at":  item.CreatedAt.Time,
+		"updated_at":  item.UpdatedAt.Time,
+	}
+}
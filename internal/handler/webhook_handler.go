@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,12 +9,20 @@ import (
 	"github.com/gin-gonic/gin"
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/petechu/idempotent-webhook-relay/internal/logic"
+	"github.com/petechu/idempotent-webhook-relay/internal/provider"
 	"github.com/petechu/idempotent-webhook-relay/internal/svc"
-	"github.com/stripe/stripe-go/v82/webhook"
 )
 
-func stripeWebhookHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
+func webhookHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		p, ok := svcCtx.Providers.Get(c.Param("provider"), c.Param("endpoint"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Unknown webhook provider",
+			})
+			return
+		}
+
 		const MaxBodyBytes = int64(65536)
 		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxBodyBytes)
 
@@ -26,18 +35,48 @@ func stripeWebhookHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
 			return
 		}
 
-		signature := c.GetHeader("Stripe-Signature")
-		event, err := webhook.ConstructEvent(payload, signature, svcCtx.Config.StripeWebhookSecret)
-		if err != nil {
-			fmt.Println("Error verifying webhook signature:", err)
+		if err := p.Verify(c.Request.Header, payload); err != nil {
+			fmt.Printf("Error verifying %s webhook signature: %v\n", p.Name(), err)
 			c.JSON(http.StatusBadRequest, gin.H{
 				"message": "Invalid signature",
 			})
 			return
 		}
 
-		l := logic.NewStoreStripeEventLogic(c.Request.Context(), svcCtx)
-		if err := l.StoreStripeEvent(event); err != nil {
+		event, err := p.Parse(c.Request.Header, payload)
+		if errors.Is(err, provider.ErrEventIgnored) {
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Webhook ignored",
+			})
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error parsing %s webhook payload: %v\n", p.Name(), err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid payload",
+			})
+			return
+		}
+		// An empty ID would be stored once and every later event would be
+		// dropped as its duplicate.
+		if event.ID == "" {
+			fmt.Printf("Error parsing %s webhook payload: missing event id\n", p.Name())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Missing event id",
+			})
+			return
+		}
+
+		l := logic.NewStoreEventLogic(c.Request.Context(), svcCtx)
+		err = l.StoreEvent(p.Name(), event)
+		if errors.Is(err, logic.ErrDuplicateEvent) {
+			c.JSON(http.StatusOK, gin.H{
+				"message":   "Webhook already received",
+				"duplicate": true,
+			})
+			return
+		}
+		if err != nil {
 			fmt.Println("Error storing event:", err)
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"message": fmt.Sprintf("Failed to store event: %s", err),
@@ -46,7 +85,8 @@ func stripeWebhookHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
 		}
 
 		c.JSON(200, gin.H{
-			"message": "Webhook received",
+			"message":   "Webhook received",
+			"duplicate": false,
 		})
 	}
 }
@@ -0,0 +1,76 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Lookup resolves a small subset of JSONPath against payload:
+// a root "$" followed by ".field" and "[index]" segments, for example
+// "$.data.object.id" or "$.events[0].type". The resolved value must be a
+// string or a number.
+func Lookup(payload []byte, path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return "", fmt.Errorf("json path %q must start with $", path)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var node any
+	if err := decoder.Decode(&node); err != nil {
+		return "", fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+
+			object, ok := node.(map[string]any)
+			if !ok {
+				return "", fmt.Errorf("json path %q: %q is not an object field", path, key)
+			}
+			if node, ok = object[key]; !ok {
+				return "", fmt.Errorf("json path %q: field %q not found", path, key)
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return "", fmt.Errorf("json path %q: unterminated index", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return "", fmt.Errorf("json path %q: invalid index: %w", path, err)
+			}
+			rest = rest[end+1:]
+
+			array, ok := node.([]any)
+			if !ok || index < 0 || index >= len(array) {
+				return "", fmt.Errorf("json path %q: index %d out of range", path, index)
+			}
+			node = array[index]
+		default:
+			return "", fmt.Errorf("json path %q: unexpected character %q", path, rest[0])
+		}
+	}
+
+	switch value := node.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	default:
+		return "", fmt.Errorf("json path %q does not resolve to a string or number", path)
+	}
+}
@@ -0,0 +1,59 @@
+package jsonpath
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	payload := []byte(`{
+		"id": "evt_1",
+		"amount": 1250,
+		"ratio": 0.5,
+		"data": {"object": {"customer": "cus_1", "tags": ["a", "b"]}},
+		"events": [{"type": "invoice.paid"}, {"type": "invoice.voided"}],
+		"empty": null,
+		"flag": true
+	}`)
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "top-level string", path: "$.id", want: "evt_1"},
+		{name: "integer", path: "$.amount", want: "1250"},
+		{name: "float", path: "$.ratio", want: "0.5"},
+		{name: "nested field", path: "$.data.object.customer", want: "cus_1"},
+		{name: "index", path: "$.events[1].type", want: "invoice.voided"},
+		{name: "index into nested array", path: "$.data.object.tags[0]", want: "a"},
+		{name: "missing field", path: "$.data.object.subscription", wantErr: true},
+		{name: "field of a string", path: "$.id.value", wantErr: true},
+		{name: "index out of range", path: "$.events[2].type", wantErr: true},
+		{name: "negative index", path: "$.events[-1].type", wantErr: true},
+		{name: "index into an object", path: "$.data[0]", wantErr: true},
+		{name: "invalid index", path: "$.events[first].type", wantErr: true},
+		{name: "unterminated index", path: "$.events[0", wantErr: true},
+		{name: "object", path: "$.data", wantErr: true},
+		{name: "array", path: "$.events", wantErr: true},
+		{name: "null", path: "$.empty", wantErr: true},
+		{name: "boolean", path: "$.flag", wantErr: true},
+		{name: "missing root", path: "id", wantErr: true},
+		{name: "unexpected character", path: "$id", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lookup(payload, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Lookup(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("Lookup(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupRejectsInvalidJSON(t *testing.T) {
+	if _, err := Lookup([]byte(`{"id":`), "$.id"); err == nil {
+		t.Fatal("Lookup() accepted invalid JSON")
+	}
+}
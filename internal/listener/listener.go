@@ -0,0 +1,76 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// outboxChannel is notified by the outbox_notify_insert trigger.
+const outboxChannel = "outbox_events"
+
+// Start runs fn right away, whenever a row is inserted into the outbox and at
+// least once per interval, so events are still relayed if a notification is
+// missed while the listener is reconnecting. The first run picks up the
+// backlog left by a restart. It returns when ctx is done.
+func Start(ctx context.Context, dbURL string, interval time.Duration, fn func()) {
+	wake := make(chan struct{}, 1)
+	wake <- struct{}{}
+	go listen(ctx, dbURL, wake)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+		fn()
+		fmt.Println(" [*] Job executed at", time.Now().Format(time.RFC3339))
+	}
+}
+
+func listen(ctx context.Context, dbURL string, wake chan<- struct{}) {
+	for {
+		err := waitForNotifications(ctx, dbURL, wake)
+		if ctx.Err() != nil {
+			return
+		}
+		fmt.Printf(" [!] Outbox listener stopped: %s; reconnecting in 5s\n", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func waitForNotifications(ctx context.Context, dbURL string, wake chan<- struct{}) error {
+	conn, err := pgx.Connect(ctx, dbURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+outboxChannel); err != nil {
+		return err
+	}
+	fmt.Printf(" [*] Listening for notifications on %q\n", outboxChannel)
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		// Coalesce bursts of inserts into a single pending run.
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/svc"
+)
+
+var (
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	// ErrDeadLetterRedriven is returned when a dead letter was already
+	// redriven or its event is no longer dead-lettered.
+	ErrDeadLetterRedriven = errors.New("dead letter was already redriven")
+)
+
+type DeadLetterLogic struct {
+	ctx context.Context
+	svc *svc.ServiceContext
+}
+
+func NewDeadLetterLogic(ctx context.Context, svc *svc.ServiceContext) *DeadLetterLogic {
+	return &DeadLetterLogic{
+		ctx: ctx,
+		svc: svc,
+	}
+}
+
+func (l *DeadLetterLogic) ListDeadLetters() ([]db.DeadLetter, error) {
+	items, err := l.svc.OutboxDB.ListDeadLetters(l.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	return items, nil
+}
+
+// Redrive resets the outbox row behind a dead letter so that the producer
+// claims and publishes it again. Each dead letter can be redriven once, and
+// only while its event is still dead_lettered.
+func (l *DeadLetterLogic) Redrive(id int32) error {
+	tx, err := l.svc.DB.Begin(l.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(l.ctx)
+
+	query := l.svc.OutboxDB.WithTx(tx)
+
+	item, err := query.GetDeadLetter(l.ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get dead letter: %w", err)
+	}
+
+	marked, err := query.MarkDeadLetterRedriven(l.ctx, item.ID)
+	if err != nil {
+		return fmt.Errorf("failed to mark dead letter as redriven: %w", err)
+	}
+	if marked == 0 {
+		return ErrDeadLetterRedriven
+	}
+	reset, err := query.ResetOutboxEvent(l.ctx, item.OutboxID)
+	if err != nil {
+		return fmt.Errorf("failed to reset outbox event: %w", err)
+	}
+	if reset == 0 {
+		return ErrDeadLetterRedriven
+	}
+
+	return tx.Commit(l.ctx)
+}
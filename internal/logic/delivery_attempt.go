@@ -0,0 +1,43 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/svc"
+)
+
+type DeliveryAttemptLogic struct {
+	ctx context.Context
+	svc *svc.ServiceContext
+}
+
+func NewDeliveryAttemptLogic(ctx context.Context, svc *svc.ServiceContext) *DeliveryAttemptLogic {
+	return &DeliveryAttemptLogic{
+		ctx: ctx,
+		svc: svc,
+	}
+}
+
+// ListAttempts returns every delivery attempt of an event, oldest first.
+func (l *DeliveryAttemptLogic) ListAttempts(providerName, eventID string) ([]db.DeliveryAttempt, error) {
+	event, err := l.svc.OutboxDB.GetOutBoxEvent(l.ctx, db.GetOutBoxEventParams{
+		Provider: providerName,
+		EventID:  eventID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	items, err := l.svc.OutboxDB.ListDeliveryAttempts(l.ctx, event.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list delivery attempts: %w", err)
+	}
+	return items, nil
+}
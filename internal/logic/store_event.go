@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/provider"
+	"github.com/petechu/idempotent-webhook-relay/internal/svc"
+)
+
+// ErrDuplicateEvent is returned when the provider's event ID is already in
+// the outbox, i.e. the provider is redelivering an event we have stored.
+var ErrDuplicateEvent = errors.New("event already exists in the outbox")
+
+type StoreEventLogic struct {
+	ctx context.Context
+	svc *svc.ServiceContext
+}
+
+func NewStoreEventLogic(ctx context.Context, svc *svc.ServiceContext) *StoreEventLogic {
+	return &StoreEventLogic{
+		ctx: ctx,
+		svc: svc,
+	}
+}
+
+func (l *StoreEventLogic) StoreEvent(providerName string, event provider.Event) error {
+	_, err := l.svc.OutboxDB.InsertOutboxEvent(l.ctx, db.InsertOutboxEventParams{
+		EventID:  event.ID,
+		Type:     event.Type,
+		Payload:  event.Payload,
+		Provider: providerName,
+		Endpoint: pgtype.Text{
+			String: event.Endpoint,
+			Valid:  event.Endpoint != "",
+		},
+		Account: pgtype.Text{
+			String: event.Account,
+			Valid:  event.Account != "",
+		},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("event with ID %s: %w", event.ID, ErrDuplicateEvent)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert outbox event: %w", err)
+	}
+
+	return nil
+}
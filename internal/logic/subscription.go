@@ -0,0 +1,173 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/svc"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrInvalidSubscription  = errors.New("invalid subscription")
+	ErrEventNotFound        = errors.New("event not found")
+)
+
+// SubscriptionRequest creates or replaces a subscription. An empty Provider
+// matches every provider, empty EventTypes match every event type and a nil
+// Enabled defaults to true. On update an empty Secret keeps the current one.
+type SubscriptionRequest struct {
+	URL        string   `json:"url"`
+	Provider   string   `json:"provider"`
+	EventTypes []string `json:"event_types"`
+	Enabled    *bool    `json:"enabled"`
+	Secret     string   `json:"secret"`
+}
+
+type SubscriptionLogic struct {
+	ctx context.Context
+	svc *svc.ServiceContext
+}
+
+func NewSubscriptionLogic(ctx context.Context, svc *svc.ServiceContext) *SubscriptionLogic {
+	return &SubscriptionLogic{
+		ctx: ctx,
+		svc: svc,
+	}
+}
+
+func (l *SubscriptionLogic) ListSubscriptions() ([]db.Subscription, error) {
+	items, err := l.svc.OutboxDB.ListSubscriptions(l.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	return items, nil
+}
+
+func (l *SubscriptionLogic) GetSubscription(id int32) (db.Subscription, error) {
+	item, err := l.svc.OutboxDB.GetSubscription(l.ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Subscription{}, ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return db.Subscription{}, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	return item, nil
+}
+
+func (l *SubscriptionLogic) CreateSubscription(req SubscriptionRequest) (db.Subscription, error) {
+	if err := req.validate(); err != nil {
+		return db.Subscription{}, err
+	}
+
+	item, err := l.svc.OutboxDB.CreateSubscription(l.ctx, db.CreateSubscriptionParams{
+		Url:        req.URL,
+		Provider:   optionalText(req.Provider),
+		EventTypes: req.eventTypes(),
+		Enabled:    req.enabled(),
+		Secret:     optionalText(req.Secret),
+	})
+	if err != nil {
+		return db.Subscription{}, fmt.Errorf("failed to create subscription: %w", err)
+	}
+	return item, nil
+}
+
+func (l *SubscriptionLogic) UpdateSubscription(id int32, req SubscriptionRequest) (db.Subscription, error) {
+	if err := req.validate(); err != nil {
+		return db.Subscription{}, err
+	}
+
+	current, err := l.GetSubscription(id)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+	secret := current.Secret
+	if req.Secret != "" {
+		secret = optionalText(req.Secret)
+	}
+
+	item, err := l.svc.OutboxDB.UpdateSubscription(l.ctx, db.UpdateSubscriptionParams{
+		ID:         id,
+		Url:        req.URL,
+		Provider:   optionalText(req.Provider),
+		EventTypes: req.eventTypes(),
+		Enabled:    req.enabled(),
+		Secret:     secret,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Subscription{}, ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return db.Subscription{}, fmt.Errorf("failed to update subscription: %w", err)
+	}
+	return item, nil
+}
+
+func (l *SubscriptionLogic) DeleteSubscription(id int32) error {
+	deleted, err := l.svc.OutboxDB.DeleteSubscription(l.ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	if deleted == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// ListDeliveries returns the per-subscription delivery status of an event.
+func (l *SubscriptionLogic) ListDeliveries(providerName, eventID string) ([]db.SubscriptionDelivery, error) {
+	event, err := l.svc.OutboxDB.GetOutBoxEvent(l.ctx, db.GetOutBoxEventParams{
+		Provider: providerName,
+		EventID:  eventID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	items, err := l.svc.OutboxDB.ListSubscriptionDeliveries(l.ctx, event.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	return items, nil
+}
+
+func (r SubscriptionRequest) validate() error {
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidSubscription)
+	}
+	for _, pattern := range r.EventTypes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: bad event type pattern %q", ErrInvalidSubscription, pattern)
+		}
+	}
+	return nil
+}
+
+func (r SubscriptionRequest) eventTypes() []string {
+	if len(r.EventTypes) == 0 {
+		return []string{"*"}
+	}
+	return r.EventTypes
+}
+
+func (r SubscriptionRequest) enabled() bool {
+	return r.Enabled == nil || *r.Enabled
+}
+
+func optionalText(s string) pgtype.Text {
+	return pgtype.Text{
+		String: s,
+		Valid:  s != "",
+	}
+}
@@ -0,0 +1,71 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const leaderCheckInterval = 5 * time.Second
+
+// RunAsLeader runs fn only while this replica holds the Postgres advisory
+// lock identified by lockID. The lock lives on a dedicated session, so it is
+// released by Postgres as soon as the leader's connection dies and a standby
+// takes over on its next attempt. fn must return once its context is done.
+func RunAsLeader(ctx context.Context, dbURL string, lockID int64, fn func(ctx context.Context)) {
+	for {
+		if err := lead(ctx, dbURL, lockID, fn); err != nil {
+			fmt.Printf(" [!] Leader election error: %s\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(leaderCheckInterval):
+		}
+	}
+}
+
+func lead(ctx context.Context, dbURL string, lockID int64, fn func(ctx context.Context)) error {
+	conn, err := pgx.Connect(ctx, dbURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", lockID).Scan(&acquired); err != nil {
+		return err
+	}
+	if !acquired {
+		return nil
+	}
+
+	fmt.Printf(" [*] Acquired leader lock %d\n", lockID)
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(leaderCtx)
+	}()
+
+	ticker := time.NewTicker(leaderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ticker.C:
+			if err := conn.Ping(leaderCtx); err != nil {
+				cancel()
+				<-done
+				return fmt.Errorf("lost leader lock session: %w", err)
+			}
+		}
+	}
+}
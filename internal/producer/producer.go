@@ -0,0 +1,206 @@
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/petechu/idempotent-webhook-relay/internal/allowlist"
+	"github.com/petechu/idempotent-webhook-relay/internal/config"
+	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/jsonpath"
+	"github.com/petechu/idempotent-webhook-relay/internal/listener"
+	"github.com/petechu/idempotent-webhook-relay/internal/queue"
+)
+
+type Producer struct {
+	ID        string
+	Context   context.Context
+	DB        *db.Queries
+	Allowlist *allowlist.Allowlist
+	Publisher queue.Publisher
+	Config    *config.Config
+}
+
+func NewProducer(ctx context.Context, cfg *config.Config, query *db.Queries, pub queue.Publisher) *Producer {
+	return &Producer{
+		ID:        producerID(cfg),
+		Context:   ctx,
+		DB:        query,
+		Allowlist: cfg.EventTypeAllowlist(),
+		Publisher: pub,
+		Config:    cfg,
+	}
+}
+
+// Run relays outbox events until ctx is done, either directly or, when
+// leader election is enabled, only while this replica is the leader.
+func (p *Producer) Run(ctx context.Context) {
+	run := func(ctx context.Context) {
+		listener.Start(ctx, p.Config.DatabaseURL(), p.Config.ProducerPollInterval, p.publishAll)
+	}
+	if p.Config.ProducerLeaderElection {
+		RunAsLeader(ctx, p.Config.DatabaseURL(), p.Config.ProducerLeaderLockID, run)
+	} else {
+		run(ctx)
+	}
+}
+
+// RecoverPending makes every pending event eligible to be published again.
+// It is only safe for a broker that lost its messages, such as the in-memory
+// broker of a previous run, and while no consumer is running.
+func (p *Producer) RecoverPending(ctx context.Context) error {
+	n, err := p.DB.ResetPendingOutboxEvents(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reset pending events: %w", err)
+	}
+	if n > 0 {
+		log.Printf("Reset %d pending events to be published again", n)
+	}
+	return nil
+}
+
+func (p *Producer) publishAll() {
+	for {
+		claimed := p.publishBatch()
+		if claimed < int(p.Config.ProducerBatchSize) {
+			return
+		}
+	}
+}
+
+// publishBatch claims up to ProducerBatchSize unprocessed events and publishes
+// them. Claimed rows are skipped by other producer replicas until the lease
+// expires, so each event is published by a single replica.
+func (p *Producer) publishBatch() int {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := p.DB.ClaimUnprocessedEvents(ctx, db.ClaimUnprocessedEventsParams{
+		ClaimedBy: pgtype.Text{
+			String: p.ID,
+			Valid:  true,
+		},
+		ClaimExpiresAt: pgtype.Timestamptz{
+			Time:  time.Now().Add(p.Config.ProducerClaimLease),
+			Valid: true,
+		},
+		BatchSize: p.Config.ProducerBatchSize,
+	})
+	if err != nil {
+		fmt.Printf(" [!] Error claiming events: %s\n", err)
+		return 0
+	}
+
+	fmt.Printf(" [*] Claimed %d events to process\n", len(events))
+
+	var (
+		published []db.Outbox
+		messages  []queue.Message
+	)
+	for _, evt := range events {
+		if !p.Allowlist.Allows(evt.Provider, evt.Type) {
+			p.ignore(ctx, evt.ID)
+			continue
+		}
+
+		payload, err := json.Marshal(evt)
+		if err != nil {
+			p.failOnError(
+				ctx,
+				evt.ID,
+				fmt.Errorf("failed to marshal event: %w", err),
+			)
+			continue
+		}
+		published = append(published, evt)
+		messages = append(messages, queue.Message{
+			Key:     p.partitionKey(evt),
+			Subject: evt.Provider + "." + evt.Type,
+			Body:    payload,
+		})
+	}
+
+	// Rows only move to pending once the broker has confirmed the message;
+	// anything else is marked failed and retried after the claim expires. A
+	// consumer may already have settled the message by then, so rows it has
+	// finished with are left alone.
+	for i, err := range p.Publisher.Publish(ctx, messages...) {
+		evt := published[i]
+		if err != nil {
+			p.failOnError(
+				ctx,
+				evt.ID,
+				fmt.Errorf("failed to publish a message: %w", err),
+			)
+			continue
+		}
+
+		err = p.DB.MarkOutboxPublished(ctx, db.MarkOutboxPublishedParams{
+			ID: evt.ID,
+			ClaimedBy: pgtype.Text{
+				String: p.ID,
+				Valid:  true,
+			},
+		})
+		if err != nil {
+			fmt.Printf(" [!] Error updating event %d: %s\n", evt.ID, err)
+			p.failOnError(ctx, evt.ID, err)
+		}
+	}
+	return len(events)
+}
+
+func (p *Producer) partitionKey(evt db.Outbox) string {
+	if p.Config.ProducerPartitionKeyPath == "" {
+		return evt.EventID
+	}
+	key, err := jsonpath.Lookup(evt.Payload, p.Config.ProducerPartitionKeyPath)
+	if err != nil || key == "" {
+		return evt.EventID
+	}
+	return key
+}
+
+func producerID(cfg *config.Config) string {
+	if cfg.ProducerID != "" {
+		return cfg.ProducerID
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "producer"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+func (p *Producer) ignore(ctx context.Context, evtID int32) {
+	if err := p.DB.UpdateOutboxEvent(ctx, db.UpdateOutboxEventParams{
+		ID: evtID,
+		Status: pgtype.Text{
+			String: "ignored",
+			Valid:  true,
+		},
+	}); err != nil {
+		fmt.Printf(" [!] Error marking event %d as ignored: %s\n", evtID, err)
+	}
+}
+
+func (p *Producer) failOnError(ctx context.Context, evtID int32, err error) {
+	if err := p.DB.UpdateOutboxEvent(ctx, db.UpdateOutboxEventParams{
+		ID: evtID,
+		Status: pgtype.Text{
+			String: "failed",
+			Valid:  true,
+		},
+		LastError: pgtype.Text{
+			String: err.Error(),
+			Valid:  true,
+		},
+	}); err != nil {
+		log.Panicf(" [!] Error updating event %d to failed: %s\n", evtID, err)
+	}
+}
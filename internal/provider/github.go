@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type GitHub struct {
+	secret string
+}
+
+func NewGitHub(secret string) *GitHub {
+	return &GitHub{
+		secret: secret,
+	}
+}
+
+func (g *GitHub) Name() string {
+	return "github"
+}
+
+func (g *GitHub) Verify(header http.Header, payload []byte) error {
+	signature, ok := strings.CutPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+	if !ok {
+		return fmt.Errorf("missing or malformed X-Hub-Signature-256 header")
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(g.secret))
+	mac.Write(payload)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func (g *GitHub) Parse(header http.Header, payload []byte) (Event, error) {
+	deliveryID := header.Get("X-GitHub-Delivery")
+	if deliveryID == "" {
+		return Event{}, fmt.Errorf("missing X-GitHub-Delivery header")
+	}
+
+	eventType := header.Get("X-GitHub-Event")
+	if eventType == "" {
+		return Event{}, fmt.Errorf("missing X-GitHub-Event header")
+	}
+
+	if !json.Valid(payload) {
+		return Event{}, fmt.Errorf("github payload is not valid JSON")
+	}
+
+	return Event{
+		ID:      deliveryID,
+		Type:    eventType,
+		Payload: payload,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func githubSignature(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestGitHubVerify(t *testing.T) {
+	const secret = "github-secret"
+	payload := []byte(`{"action":"opened"}`)
+	valid := githubSignature(secret, payload)
+
+	tests := []struct {
+		name      string
+		signature string
+		payload   []byte
+		wantErr   bool
+	}{
+		{name: "valid signature", signature: valid, payload: payload},
+		{name: "tampered body", signature: valid, payload: []byte(`{"action":"closed"}`), wantErr: true},
+		{name: "wrong secret", signature: githubSignature("other", payload), payload: payload, wantErr: true},
+		{name: "missing prefix", signature: valid[len("sha256="):], payload: payload, wantErr: true},
+		{name: "sha1 prefix", signature: "sha1=" + valid[len("sha256="):], payload: payload, wantErr: true},
+		{name: "missing header", payload: payload, wantErr: true},
+		{name: "not hex", signature: "sha256=zz", payload: payload, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.signature != "" {
+				header.Set("X-Hub-Signature-256", tt.signature)
+			}
+			err := NewGitHub(secret).Verify(header, tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGitHubParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		delivery string
+		event    string
+		payload  string
+		wantErr  bool
+	}{
+		{name: "valid", delivery: "72d3162e", event: "push", payload: `{"ref":"refs/heads/main"}`},
+		{name: "missing delivery", event: "push", payload: `{}`, wantErr: true},
+		{name: "missing event", delivery: "72d3162e", payload: `{}`, wantErr: true},
+		{name: "invalid json", delivery: "72d3162e", event: "push", payload: `{`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set("X-GitHub-Delivery", tt.delivery)
+			header.Set("X-GitHub-Event", tt.event)
+
+			event, err := NewGitHub("secret").Parse(header, []byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if event.ID != tt.delivery || event.Type != tt.event || string(event.Payload) != tt.payload {
+				t.Fatalf("Parse() = %+v", event)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+
+	"github.com/petechu/idempotent-webhook-relay/internal/jsonpath"
+)
+
+type HMACOptions struct {
+	Name          string
+	Secret        string
+	Header        string
+	Algorithm     string
+	Encoding      string
+	Prefix        string
+	EventIDPath   string
+	EventTypePath string
+}
+
+// HMAC is a declarative provider for vendors that sign the raw request body
+// with a shared secret and send the digest in a single header.
+type HMAC struct {
+	opts    HMACOptions
+	newHash func() hash.Hash
+	decode  func(string) ([]byte, error)
+}
+
+func NewHMAC(opts HMACOptions) (*HMAC, error) {
+	if opts.Name == "" || opts.Secret == "" || opts.Header == "" {
+		return nil, fmt.Errorf("hmac provider requires a name, secret and header")
+	}
+	if opts.EventIDPath == "" || opts.EventTypePath == "" {
+		return nil, fmt.Errorf("hmac provider %q requires event id and type paths", opts.Name)
+	}
+
+	p := &HMAC{opts: opts}
+
+	switch strings.ToLower(opts.Algorithm) {
+	case "sha1":
+		p.newHash = sha1.New
+	case "sha256", "":
+		p.newHash = sha256.New
+	case "sha512":
+		p.newHash = sha512.New
+	default:
+		return nil, fmt.Errorf("hmac provider %q: unsupported algorithm %q", opts.Name, opts.Algorithm)
+	}
+
+	switch strings.ToLower(opts.Encoding) {
+	case "hex", "":
+		p.decode = hex.DecodeString
+	case "base64":
+		p.decode = base64.StdEncoding.DecodeString
+	default:
+		return nil, fmt.Errorf("hmac provider %q: unsupported encoding %q", opts.Name, opts.Encoding)
+	}
+
+	return p, nil
+}
+
+func (p *HMAC) Name() string {
+	return p.opts.Name
+}
+
+func (p *HMAC) Verify(header http.Header, payload []byte) error {
+	signature, ok := strings.CutPrefix(header.Get(p.opts.Header), p.opts.Prefix)
+	if !ok || signature == "" {
+		return fmt.Errorf("missing or malformed %s header", p.opts.Header)
+	}
+
+	expected, err := p.decode(signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	mac := hmac.New(p.newHash, []byte(p.opts.Secret))
+	mac.Write(payload)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func (p *HMAC) Parse(header http.Header, payload []byte) (Event, error) {
+	id, err := jsonpath.Lookup(payload, p.opts.EventIDPath)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to extract event id: %w", err)
+	}
+	if id == "" {
+		return Event{}, fmt.Errorf("event id at %s is empty", p.opts.EventIDPath)
+	}
+
+	eventType, err := jsonpath.Lookup(payload, p.opts.EventTypePath)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to extract event type: %w", err)
+	}
+
+	return Event{
+		ID:      id,
+		Type:    eventType,
+		Payload: payload,
+	}, nil
+}
@@ -0,0 +1,207 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"testing"
+)
+
+func hmacDigest(newHash func() hash.Hash, secret string, payload []byte) []byte {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func TestHMACVerify(t *testing.T) {
+	const secret = "hmac-secret"
+	payload := []byte(`{"id":"evt_1","type":"order.created"}`)
+
+	tests := []struct {
+		name      string
+		opts      HMACOptions
+		signature string
+		payload   []byte
+		wantErr   bool
+	}{
+		{
+			name:      "sha256 hex",
+			signature: hex.EncodeToString(hmacDigest(sha256.New, secret, payload)),
+			payload:   payload,
+		},
+		{
+			name:      "sha1 hex",
+			opts:      HMACOptions{Algorithm: "sha1"},
+			signature: hex.EncodeToString(hmacDigest(sha1.New, secret, payload)),
+			payload:   payload,
+		},
+		{
+			name:      "sha512 base64",
+			opts:      HMACOptions{Algorithm: "SHA512", Encoding: "base64"},
+			signature: base64.StdEncoding.EncodeToString(hmacDigest(sha512.New, secret, payload)),
+			payload:   payload,
+		},
+		{
+			name:      "prefixed",
+			opts:      HMACOptions{Prefix: "sha256="},
+			signature: "sha256=" + hex.EncodeToString(hmacDigest(sha256.New, secret, payload)),
+			payload:   payload,
+		},
+		{
+			name:      "tampered body",
+			signature: hex.EncodeToString(hmacDigest(sha256.New, secret, payload)),
+			payload:   []byte(`{"id":"evt_1","type":"order.deleted"}`),
+			wantErr:   true,
+		},
+		{
+			name:      "wrong algorithm",
+			opts:      HMACOptions{Algorithm: "sha512"},
+			signature: hex.EncodeToString(hmacDigest(sha256.New, secret, payload)),
+			payload:   payload,
+			wantErr:   true,
+		},
+		{
+			name:      "wrong encoding",
+			opts:      HMACOptions{Encoding: "base64"},
+			signature: hex.EncodeToString(hmacDigest(sha256.New, secret, payload)),
+			payload:   payload,
+			wantErr:   true,
+		},
+		{
+			name:      "missing prefix",
+			opts:      HMACOptions{Prefix: "sha256="},
+			signature: hex.EncodeToString(hmacDigest(sha256.New, secret, payload)),
+			payload:   payload,
+			wantErr:   true,
+		},
+		{
+			name:      "wrong prefix",
+			opts:      HMACOptions{Prefix: "sha256="},
+			signature: "sha1=" + hex.EncodeToString(hmacDigest(sha256.New, secret, payload)),
+			payload:   payload,
+			wantErr:   true,
+		},
+		{
+			name:      "prefix only",
+			opts:      HMACOptions{Prefix: "sha256="},
+			signature: "sha256=",
+			payload:   payload,
+			wantErr:   true,
+		},
+		{
+			name:    "missing header",
+			payload: payload,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Name = "acme"
+			opts.Secret = secret
+			opts.Header = "X-Signature"
+			opts.EventIDPath = "$.id"
+			opts.EventTypePath = "$.type"
+			p, err := NewHMAC(opts)
+			if err != nil {
+				t.Fatalf("NewHMAC() error = %v", err)
+			}
+
+			header := http.Header{}
+			if tt.signature != "" {
+				header.Set("X-Signature", tt.signature)
+			}
+			err = p.Verify(header, tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewHMACRejectsInvalidOptions(t *testing.T) {
+	valid := HMACOptions{
+		Name:          "acme",
+		Secret:        "secret",
+		Header:        "X-Signature",
+		EventIDPath:   "$.id",
+		EventTypePath: "$.type",
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*HMACOptions)
+	}{
+		{name: "missing secret", modify: func(o *HMACOptions) { o.Secret = "" }},
+		{name: "missing header", modify: func(o *HMACOptions) { o.Header = "" }},
+		{name: "missing id path", modify: func(o *HMACOptions) { o.EventIDPath = "" }},
+		{name: "unknown algorithm", modify: func(o *HMACOptions) { o.Algorithm = "md5" }},
+		{name: "unknown encoding", modify: func(o *HMACOptions) { o.Encoding = "base32" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := valid
+			tt.modify(&opts)
+			if _, err := NewHMAC(opts); err == nil {
+				t.Fatal("NewHMAC() accepted invalid options")
+			}
+		})
+	}
+}
+
+func TestHMACParse(t *testing.T) {
+	p, err := NewHMAC(HMACOptions{
+		Name:          "acme",
+		Secret:        "secret",
+		Header:        "X-Signature",
+		EventIDPath:   "$.data.id",
+		EventTypePath: "$.events[0].type",
+	})
+	if err != nil {
+		t.Fatalf("NewHMAC() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		payload  string
+		wantID   string
+		wantType string
+		wantErr  bool
+	}{
+		{
+			name:     "string id",
+			payload:  `{"data":{"id":"ord_1"},"events":[{"type":"order.created"}]}`,
+			wantID:   "ord_1",
+			wantType: "order.created",
+		},
+		{
+			name:     "numeric id",
+			payload:  `{"data":{"id":12345678901234567890},"events":[{"type":"order.created"}]}`,
+			wantID:   "12345678901234567890",
+			wantType: "order.created",
+		},
+		{name: "empty id", payload: `{"data":{"id":""},"events":[{"type":"order.created"}]}`, wantErr: true},
+		{name: "missing id", payload: `{"data":{},"events":[{"type":"order.created"}]}`, wantErr: true},
+		{name: "missing type", payload: `{"data":{"id":"ord_1"},"events":[]}`, wantErr: true},
+		{name: "invalid json", payload: `{`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := p.Parse(http.Header{}, []byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if event.ID != tt.wantID || event.Type != tt.wantType {
+				t.Fatalf("Parse() = %+v, want id %q and type %q", event, tt.wantID, tt.wantType)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ErrEventIgnored is returned by Parse for authentic events that the endpoint
+// is not configured to accept. They are acknowledged but not stored.
+var ErrEventIgnored = errors.New("event type is not accepted by this endpoint")
+
+// Event is the provider-agnostic form of an inbound webhook, ready to be
+// written to the outbox.
+type Event struct {
+	ID       string
+	Type     string
+	Payload  []byte
+	Endpoint string
+	Account  string
+}
+
+// Provider verifies and decodes webhooks sent by a single upstream service.
+// Verify is always called before Parse.
+type Provider interface {
+	Name() string
+	Verify(header http.Header, payload []byte) error
+	Parse(header http.Header, payload []byte) (Event, error)
+}
+
+type registryKey struct {
+	name     string
+	endpoint string
+}
+
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[registryKey]Provider
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		providers: make(map[registryKey]Provider),
+	}
+}
+
+// Register mounts p as the default endpoint of its provider.
+func (r *Registry) Register(p Provider) error {
+	return r.RegisterEndpoint("", p)
+}
+
+// RegisterEndpoint mounts p under a named endpoint of its provider, allowing
+// several accounts of the same provider to use different secrets.
+func (r *Registry) RegisterEndpoint(endpoint string, p Provider) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := registryKey{name: p.Name(), endpoint: endpoint}
+	if key.name == "" {
+		return fmt.Errorf("provider name must not be empty")
+	}
+	if _, ok := r.providers[key]; ok {
+		return fmt.Errorf("provider %q is already registered", key)
+	}
+	r.providers[key] = p
+	return nil
+}
+
+func (r *Registry) Get(name, endpoint string) (Provider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.providers[registryKey{name: name, endpoint: endpoint}]
+	return p, ok
+}
+
+// Names lists the registered providers as "name" or "name/endpoint".
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.providers))
+	for key := range r.providers {
+		names = append(names, key.String())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (k registryKey) String() string {
+	if k.endpoint == "" {
+		return k.name
+	}
+	return k.name + "/" + k.endpoint
+}
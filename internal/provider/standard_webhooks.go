@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StandardWebhooks verifies webhooks signed according to the Standard
+// Webhooks specification (https://www.standardwebhooks.com), as used by Svix
+// and a growing number of vendors.
+type StandardWebhooks struct {
+	name      string
+	key       []byte
+	tolerance time.Duration
+}
+
+func NewStandardWebhooks(name, secret string, tolerance time.Duration) (*StandardWebhooks, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret for %q: %w", name, err)
+	}
+
+	return &StandardWebhooks{
+		name:      name,
+		key:       key,
+		tolerance: tolerance,
+	}, nil
+}
+
+func (s *StandardWebhooks) Name() string {
+	return s.name
+}
+
+func (s *StandardWebhooks) Verify(header http.Header, payload []byte) error {
+	id := header.Get("webhook-id")
+	timestamp := header.Get("webhook-timestamp")
+	signatures := header.Get("webhook-signature")
+	if id == "" || timestamp == "" || signatures == "" {
+		return fmt.Errorf("missing required webhook headers")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid webhook-timestamp header: %w", err)
+	}
+	if s.tolerance > 0 {
+		age := time.Since(time.Unix(seconds, 0))
+		if age > s.tolerance || age < -s.tolerance {
+			return fmt.Errorf("timestamp outside of tolerance")
+		}
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s.%s.", id, timestamp)
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	for _, versioned := range strings.Fields(signatures) {
+		version, signature, ok := strings.Cut(versioned, ",")
+		if !ok || version != "v1" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(signature)
+		if err != nil {
+			continue
+		}
+		if hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return fmt.Errorf("no matching signature found")
+}
+
+func (s *StandardWebhooks) Parse(header http.Header, payload []byte) (Event, error) {
+	var body struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return Event{}, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	return Event{
+		ID:      header.Get("webhook-id"),
+		Type:    body.Type,
+		Payload: payload,
+	}, nil
+}
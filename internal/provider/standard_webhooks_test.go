@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var standardWebhooksKey = []byte("standard-webhooks-test-key")
+
+func standardWebhooksSignature(key []byte, id, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + timestamp + "."))
+	mac.Write(payload)
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newTestStandardWebhooks(t *testing.T) *StandardWebhooks {
+	t.Helper()
+
+	secret := "whsec_" + base64.StdEncoding.EncodeToString(standardWebhooksKey)
+	p, err := NewStandardWebhooks("svix", secret, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("NewStandardWebhooks() error = %v", err)
+	}
+	return p
+}
+
+func TestStandardWebhooksVerify(t *testing.T) {
+	const id = "msg_2KWPBgLlAfxdpx2AI54pPJ85f4W"
+	payload := []byte(`{"type":"invoice.paid"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	valid := standardWebhooksSignature(standardWebhooksKey, id, now, payload)
+	other := standardWebhooksSignature([]byte("other-key"), id, now, payload)
+
+	tests := []struct {
+		name       string
+		timestamp  string
+		signatures string
+		payload    []byte
+		wantErr    bool
+	}{
+		{name: "valid signature", timestamp: now, signatures: valid, payload: payload},
+		{name: "valid among several", timestamp: now, signatures: other + " " + valid, payload: payload},
+		{name: "tampered body", timestamp: now, signatures: valid, payload: []byte(`{"type":"invoice.voided"}`), wantErr: true},
+		{name: "wrong key", timestamp: now, signatures: other, payload: payload, wantErr: true},
+		{name: "unknown version", timestamp: now, signatures: "v2," + valid[len("v1,"):], payload: payload, wantErr: true},
+		{name: "missing version", timestamp: now, signatures: valid[len("v1,"):], payload: payload, wantErr: true},
+		{name: "not base64", timestamp: now, signatures: "v1,!!!", payload: payload, wantErr: true},
+		{
+			name:       "timestamp too old",
+			timestamp:  strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10),
+			signatures: standardWebhooksSignature(standardWebhooksKey, id, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10), payload),
+			payload:    payload,
+			wantErr:    true,
+		},
+		{
+			name:       "timestamp in the future",
+			timestamp:  strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10),
+			signatures: standardWebhooksSignature(standardWebhooksKey, id, strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10), payload),
+			payload:    payload,
+			wantErr:    true,
+		},
+		{name: "signature for another timestamp", timestamp: "1", signatures: valid, payload: payload, wantErr: true},
+		{name: "invalid timestamp", timestamp: "yesterday", signatures: valid, payload: payload, wantErr: true},
+		{name: "missing signature", timestamp: now, payload: payload, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set("webhook-id", id)
+			header.Set("webhook-timestamp", tt.timestamp)
+			header.Set("webhook-signature", tt.signatures)
+
+			err := newTestStandardWebhooks(t).Verify(header, tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewStandardWebhooksRejectsInvalidSecret(t *testing.T) {
+	if _, err := NewStandardWebhooks("svix", "whsec_not base64!", time.Minute); err == nil {
+		t.Fatal("NewStandardWebhooks() accepted a secret that is not base64")
+	}
+}
+
+func TestStandardWebhooksParse(t *testing.T) {
+	header := http.Header{}
+	header.Set("webhook-id", "msg_1")
+
+	p := newTestStandardWebhooks(t)
+	event, err := p.Parse(header, []byte(`{"type":"invoice.paid","data":{}}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if event.ID != "msg_1" || event.Type != "invoice.paid" {
+		t.Fatalf("Parse() = %+v", event)
+	}
+
+	if _, err := p.Parse(header, []byte(`{`)); err == nil {
+		t.Fatal("Parse() accepted invalid JSON")
+	}
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/petechu/idempotent-webhook-relay/internal/allowlist"
+	"github.com/stripe/stripe-go/v82"
+	"github.com/stripe/stripe-go/v82/webhook"
+)
+
+// StripeSecret is an endpoint signing secret. A zero ExpiresAt never expires.
+type StripeSecret struct {
+	Value     string
+	ExpiresAt time.Time
+}
+
+func (s StripeSecret) active(now time.Time) bool {
+	return s.Value != "" && (s.ExpiresAt.IsZero() || now.Before(s.ExpiresAt))
+}
+
+type StripeOptions struct {
+	// Endpoint names the Stripe account or Connect platform this instance
+	// serves. It is empty for the default /stripe/webhook endpoint.
+	Endpoint string
+	// Secrets are tried in order; the first one is the current secret.
+	Secrets []StripeSecret
+	// AllowedEventTypes limits which event types are stored, using the same
+	// path.Match patterns as the producer allowlist. Empty allows all.
+	AllowedEventTypes []string
+}
+
+// Stripe verifies against every active secret so that endpoint secrets can be
+// rolled without rejecting deliveries signed with the previous one.
+type Stripe struct {
+	opts StripeOptions
+}
+
+func NewStripe(opts StripeOptions) *Stripe {
+	return &Stripe{opts: opts}
+}
+
+func (s *Stripe) Name() string {
+	return "stripe"
+}
+
+func (s *Stripe) Verify(header http.Header, payload []byte) error {
+	signature := header.Get("Stripe-Signature")
+	now := time.Now()
+
+	err := errors.New("no active webhook secret configured")
+	for i, secret := range s.opts.Secrets {
+		if !secret.active(now) {
+			continue
+		}
+		if err = webhook.ValidatePayload(payload, signature, secret.Value); err != nil {
+			continue
+		}
+		if i > 0 {
+			log.Printf("%s: signature matched previous secret #%d (%s)\n", s.label(), i, redactSecret(secret.Value))
+		}
+		return nil
+	}
+	return err
+}
+
+func (s *Stripe) Parse(header http.Header, payload []byte) (Event, error) {
+	var event stripe.Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return Event{}, fmt.Errorf("failed to unmarshal stripe event: %w", err)
+	}
+	if event.ID == "" {
+		return Event{}, fmt.Errorf("stripe event is missing an id")
+	}
+
+	if len(s.opts.AllowedEventTypes) > 0 && !allowlist.MatchAny(s.opts.AllowedEventTypes, string(event.Type)) {
+		return Event{}, fmt.Errorf("%w: %s", ErrEventIgnored, event.Type)
+	}
+
+	normalized, err := json.Marshal(event)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return Event{
+		ID:       event.ID,
+		Type:     string(event.Type),
+		Payload:  normalized,
+		Endpoint: s.opts.Endpoint,
+		Account:  event.Account,
+	}, nil
+}
+
+func (s *Stripe) label() string {
+	if s.opts.Endpoint == "" {
+		return s.Name()
+	}
+	return s.Name() + "/" + s.opts.Endpoint
+}
+
+// redactSecret keeps only the last four characters of a secret so that logs
+// identify which secret matched without leaking it.
+func redactSecret(secret string) string {
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return "..." + secret[len(secret)-4:]
+}
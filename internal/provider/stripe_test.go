@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestStripeParseAllowedEventTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		typ     string
+		ignored bool
+	}{
+		{name: "no allowlist", typ: "invoice.paid"},
+		{name: "exact match", allowed: []string{"invoice.paid"}, typ: "invoice.paid"},
+		{name: "wildcard match", allowed: []string{"account.updated", "invoice.*"}, typ: "invoice.paid"},
+		{name: "no match", allowed: []string{"invoice.*"}, typ: "charge.refunded", ignored: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStripe(StripeOptions{AllowedEventTypes: tt.allowed})
+			event, err := s.Parse(http.Header{}, []byte(`{"id":"evt_1","object":"event","type":"`+tt.typ+`"}`))
+			if tt.ignored {
+				if !errors.Is(err, ErrEventIgnored) {
+					t.Fatalf("Parse() error = %v, want ErrEventIgnored", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if event.ID != "evt_1" || event.Type != tt.typ {
+				t.Fatalf("Parse() = %+v", event)
+			}
+		})
+	}
+}
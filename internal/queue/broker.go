@@ -0,0 +1,50 @@
+package queue
+
+import "context"
+
+// Message is a broker-neutral relay message.
+type Message struct {
+	// Key groups related messages on brokers that partition, such as Kafka.
+	// Messages with the same key are delivered in publish order.
+	Key string
+	// Subject routes the message on brokers with subject-based routing, such
+	// as NATS, relative to the broker's subject prefix.
+	Subject string
+	Body    []byte
+	Headers map[string]any
+}
+
+// Acknowledger settles a delivery with the broker it came from.
+type Acknowledger interface {
+	Ack() error
+	Nack(requeue bool) error
+	// DeadLetter moves the message to the broker's dead-letter destination
+	// with headers describing the failure. It settles the delivery.
+	DeadLetter(ctx context.Context, headers map[string]any) error
+}
+
+type Delivery struct {
+	Message
+	Acknowledger
+	// Attempt counts how many times the broker has delivered the message,
+	// starting at 1, on brokers that track it, such as NATS. It is 0
+	// otherwise.
+	Attempt int
+}
+
+// Publisher publishes messages and reports, per message, whether the broker
+// accepted it: nil once acknowledged, or the reason it was not.
+type Publisher interface {
+	Publish(ctx context.Context, msgs ...Message) []error
+	Close()
+}
+
+// Handler receives every delivery and must eventually settle it.
+type Handler func(ctx context.Context, d Delivery)
+
+// Subscriber calls handler for each delivery until ctx is done or the
+// subscriber is closed.
+type Subscriber interface {
+	Subscribe(ctx context.Context, handler Handler) error
+	Close()
+}
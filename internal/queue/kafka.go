@@ -0,0 +1,532 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka is the Kafka Publisher and Subscriber. Messages are partitioned by
+// Message.Key so that events sharing a key keep their order, and consumer
+// group offsets are only committed once a delivery has been settled.
+type Kafka struct {
+	opts   KafkaOptions
+	writer *kafka.Writer
+
+	mu     sync.Mutex
+	reader *kafka.Reader
+	closed bool
+}
+
+type KafkaOptions struct {
+	Brokers []string
+	Topic   string
+	// DeadLetterTopic receives messages that are rejected without requeue or
+	// dead-lettered by the consumer.
+	DeadLetterTopic string
+	GroupID         string
+	// MaxPending bounds how many fetched messages may be waiting or in
+	// progress at once, including those queued behind a busy key.
+	MaxPending int
+}
+
+var (
+	_ Publisher  = (*Kafka)(nil)
+	_ Subscriber = (*Kafka)(nil)
+)
+
+func (o KafkaOptions) maxPending() int {
+	if o.MaxPending <= 0 {
+		return 1000
+	}
+	return o.MaxPending
+}
+
+func NewKafka(opts KafkaOptions) (*Kafka, error) {
+	if len(opts.Brokers) == 0 {
+		return nil, errors.New("no kafka brokers configured")
+	}
+	if opts.Topic == "" {
+		return nil, errors.New("no kafka topic configured")
+	}
+
+	return &Kafka{
+		opts: opts,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(opts.Brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+			// Every write is synchronous and already carries a whole batch,
+			// so do not wait for the default 1s to collect more messages.
+			BatchTimeout: time.Millisecond,
+		},
+	}, nil
+}
+
+// Publish writes every message to the topic and waits until all in-sync
+// replicas have acknowledged them.
+func (k *Kafka) Publish(ctx context.Context, msgs ...Message) []error {
+	errs := make([]error, len(msgs))
+	if len(msgs) == 0 {
+		return errs
+	}
+
+	records := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		records[i] = k.record(k.opts.Topic, msg)
+	}
+
+	err := k.writer.WriteMessages(ctx, records...)
+	var writeErrs kafka.WriteErrors
+	switch {
+	case err == nil:
+	case errors.As(err, &writeErrs):
+		for i, err := range writeErrs {
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to publish message: %w", err)
+			}
+		}
+	default:
+		for i := range errs {
+			errs[i] = fmt.Errorf("failed to publish message: %w", err)
+		}
+	}
+	return errs
+}
+
+// Subscribe joins the consumer group and delivers messages to handler until
+// ctx is done or the subscriber is closed. Messages with different keys are
+// delivered concurrently and may be settled out of order; a message is only
+// handed out once every earlier message with the same key has been settled,
+// so one slow key never holds up the others. A partition's offset only
+// advances past contiguously settled messages.
+func (k *Kafka) Subscribe(ctx context.Context, handler Handler) error {
+	reader, err := k.newReader()
+	if err != nil {
+		return err
+	}
+
+	s := &kafkaSubscription{
+		ctx:     ctx,
+		broker:  k,
+		reader:  reader,
+		offsets: newOffsetTracker(),
+		keys:    newKeyQueues(),
+		slots:   make(chan struct{}, k.opts.maxPending()),
+		wake:    make(chan struct{}, 1),
+	}
+
+	fetched := make(chan kafka.Message)
+	fetchErr := make(chan error, 1)
+	go s.fetch(fetched, fetchErr)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-fetchErr:
+			if ctx.Err() != nil || k.isClosed() {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch message: %w", err)
+		case m := <-fetched:
+			s.offsets.add(m.Partition, m.Offset)
+			if s.keys.push(m) {
+				s.deliver(handler, &kafkaDelivery{msg: m})
+			}
+		case <-s.wake:
+			for _, d := range s.takeReady() {
+				s.deliver(handler, d)
+			}
+		}
+	}
+}
+
+func (k *Kafka) Close() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.closed {
+		return
+	}
+	k.closed = true
+
+	var errs error
+	if err := k.writer.Close(); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("failed to close writer: %w", err))
+	}
+	if k.reader != nil {
+		if err := k.reader.Close(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to close reader: %w", err))
+		}
+	}
+	if errs != nil {
+		fmt.Println("Queue close error:", errs)
+	}
+}
+
+func (k *Kafka) newReader() (*kafka.Reader, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.closed {
+		return nil, ErrClosed
+	}
+	if k.reader != nil {
+		return nil, errors.New("kafka subscriber is already running")
+	}
+	if k.opts.GroupID == "" {
+		return nil, errors.New("no kafka consumer group configured")
+	}
+
+	k.reader = kafka.NewReader(kafka.ReaderConfig{
+		Brokers: k.opts.Brokers,
+		GroupID: k.opts.GroupID,
+		Topic:   k.opts.Topic,
+		// Commit synchronously so that an acknowledged offset is durable
+		// before the next delivery is settled.
+		CommitInterval: 0,
+	})
+	return k.reader, nil
+}
+
+func (k *Kafka) isClosed() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.closed
+}
+
+func (k *Kafka) record(topic string, msg Message) kafka.Message {
+	headers := make([]kafka.Header, 0, len(msg.Headers))
+	for key, v := range msg.Headers {
+		headers = append(headers, kafka.Header{Key: key, Value: fmt.Append(nil, v)})
+	}
+	return kafka.Message{
+		Topic:   topic,
+		Key:     []byte(msg.Key),
+		Value:   msg.Body,
+		Headers: headers,
+	}
+}
+
+// republish writes a consumed message to topic with extra headers merged in.
+func (k *Kafka) republish(ctx context.Context, topic string, m kafka.Message, headers map[string]any) error {
+	msg := Message{
+		Key:     string(m.Key),
+		Body:    m.Value,
+		Headers: kafkaHeaders(m.Headers),
+	}
+	for key, v := range headers {
+		msg.Headers[key] = v
+	}
+	return k.writer.WriteMessages(ctx, k.record(topic, msg))
+}
+
+func kafkaHeaders(headers []kafka.Header) map[string]any {
+	out := make(map[string]any, len(headers))
+	for _, h := range headers {
+		out[h.Key] = string(h.Value)
+	}
+	return out
+}
+
+// kafkaSubscription tracks the messages fetched by one Subscribe call until
+// they are settled.
+type kafkaSubscription struct {
+	ctx     context.Context
+	broker  *Kafka
+	reader  *kafka.Reader
+	offsets *offsetTracker
+	keys    *keyQueues
+	// slots bounds how many fetched messages may be unsettled at once.
+	slots chan struct{}
+
+	mu    sync.Mutex
+	ready []*kafkaDelivery
+	wake  chan struct{}
+}
+
+type kafkaDelivery struct {
+	msg         kafka.Message
+	redelivered int
+}
+
+func (s *kafkaSubscription) fetch(fetched chan<- kafka.Message, fetchErr chan<- error) {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case s.slots <- struct{}{}:
+		}
+
+		m, err := s.reader.FetchMessage(s.ctx)
+		if err != nil {
+			fetchErr <- err
+			return
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case fetched <- m:
+		}
+	}
+}
+
+func (s *kafkaSubscription) deliver(handler Handler, d *kafkaDelivery) {
+	handler(s.ctx, Delivery{
+		Message: Message{
+			Key:     string(d.msg.Key),
+			Body:    d.msg.Value,
+			Headers: kafkaHeaders(d.msg.Headers),
+		},
+		Acknowledger: &kafkaAcknowledger{sub: s, delivery: d},
+	})
+}
+
+// enqueue hands d back to the Subscribe loop without blocking, so that it
+// can be called while settling a delivery.
+func (s *kafkaSubscription) enqueue(d *kafkaDelivery) {
+	s.mu.Lock()
+	s.ready = append(s.ready, d)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *kafkaSubscription) takeReady() []*kafkaDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ready := s.ready
+	s.ready = nil
+	return ready
+}
+
+// complete commits the message's offset once every earlier message of its
+// partition is settled and hands out the next message with the same key. A
+// failed commit is reported but does not hold anything up: the next commit
+// of the partition covers this offset too.
+func (s *kafkaSubscription) complete(m kafka.Message) error {
+	defer func() {
+		<-s.slots
+		if next, ok := s.keys.pop(string(m.Key)); ok {
+			s.enqueue(&kafkaDelivery{msg: next})
+		}
+	}()
+
+	offset, ok := s.offsets.settle(m.Partition, m.Offset)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.reader.CommitMessages(ctx, kafka.Message{
+		Topic:     m.Topic,
+		Partition: m.Partition,
+		Offset:    offset,
+	}); err != nil {
+		return fmt.Errorf("failed to commit offset: %w", err)
+	}
+	return nil
+}
+
+// redeliver hands d to the handler again after a backoff. Its key stays held
+// meanwhile, so later messages with the same key keep waiting behind it.
+func (s *kafkaSubscription) redeliver(d *kafkaDelivery) {
+	d.redelivered++
+	delay := min(time.Duration(1<<min(d.redelivered-1, 5))*time.Second, 30*time.Second)
+	time.AfterFunc(delay, func() { s.enqueue(d) })
+}
+
+// deadLetter writes m to the dead-letter topic, retrying until it succeeds
+// or the subscription stops. Moving on without it would either lose the
+// message or stall its partition.
+func (s *kafkaSubscription) deadLetter(m kafka.Message, headers map[string]any) error {
+	topic := s.broker.opts.DeadLetterTopic
+	if topic == "" {
+		log.Printf("Dropping message at offset %d: no dead-letter topic configured", m.Offset)
+		return nil
+	}
+
+	merged := map[string]any{"x-original-topic": m.Topic}
+	for k, v := range headers {
+		merged[k] = v
+	}
+
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+		err := s.broker.republish(ctx, topic, m, merged)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		log.Printf("Failed to dead-letter message at offset %d (attempt %d): %v", m.Offset, attempt, err)
+
+		select {
+		case <-s.ctx.Done():
+			return fmt.Errorf("failed to dead-letter message: %w", err)
+		case <-time.After(min(time.Duration(attempt)*time.Second, 30*time.Second)):
+		}
+	}
+}
+
+type kafkaAcknowledger struct {
+	sub      *kafkaSubscription
+	delivery *kafkaDelivery
+	once     sync.Once
+}
+
+func (a *kafkaAcknowledger) settle(fn func() error) error {
+	err := errAlreadySettled
+	a.once.Do(func() {
+		err = fn()
+	})
+	return err
+}
+
+func (a *kafkaAcknowledger) Ack() error {
+	return a.settle(func() error {
+		return a.sub.complete(a.delivery.msg)
+	})
+}
+
+// Nack with requeue redelivers the message in place rather than appending it
+// to the topic, which would let later messages with the same key overtake it.
+func (a *kafkaAcknowledger) Nack(requeue bool) error {
+	if requeue {
+		return a.settle(func() error {
+			a.sub.redeliver(a.delivery)
+			return nil
+		})
+	}
+	return a.DeadLetter(context.Background(), nil)
+}
+
+// DeadLetter only commits the offset once the message is on the dead-letter
+// topic. If the subscription stops first, the message is redelivered after
+// a restart.
+func (a *kafkaAcknowledger) DeadLetter(ctx context.Context, headers map[string]any) error {
+	return a.settle(func() error {
+		if err := a.sub.deadLetter(a.delivery.msg, headers); err != nil {
+			return err
+		}
+		return a.sub.complete(a.delivery.msg)
+	})
+}
+
+// offsetTracker records, per partition, which fetched offsets have been
+// settled so that commits never skip over a message still being processed.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[int]*partitionOffsets
+}
+
+type partitionOffsets struct {
+	pending []int64
+	settled map[int64]bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[int]*partitionOffsets)}
+}
+
+func (t *offsetTracker) add(partition int, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[partition]
+	if !ok {
+		p = &partitionOffsets{settled: make(map[int64]bool)}
+		t.partitions[partition] = p
+	}
+	p.pending = append(p.pending, offset)
+}
+
+// settle marks offset as done and returns the highest offset below which
+// every fetched message of the partition has been settled.
+func (t *offsetTracker) settle(partition int, offset int64) (int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[partition]
+	if !ok {
+		return 0, false
+	}
+	p.settled[offset] = true
+
+	var (
+		committed int64
+		advanced  bool
+	)
+	for len(p.pending) > 0 && p.settled[p.pending[0]] {
+		committed = p.pending[0]
+		delete(p.settled, committed)
+		p.pending = p.pending[1:]
+		advanced = true
+	}
+	return committed, advanced
+}
+
+// keyQueues holds, per partition key, the messages fetched while an earlier
+// message with the same key is unsettled. A key is busy while it has an
+// entry, even an empty one.
+type keyQueues struct {
+	mu     sync.Mutex
+	queues map[string][]kafka.Message
+}
+
+func newKeyQueues() *keyQueues {
+	return &keyQueues{queues: make(map[string][]kafka.Message)}
+}
+
+// push reports whether m can be delivered now, or queues it behind the
+// unsettled message with the same key. Unkeyed messages never wait.
+func (q *keyQueues) push(m kafka.Message) bool {
+	key := string(m.Key)
+	if key == "" {
+		return true
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	backlog, busy := q.queues[key]
+	if busy {
+		q.queues[key] = append(backlog, m)
+		return false
+	}
+	q.queues[key] = nil
+	return true
+}
+
+// pop is called once a key's message is settled and returns the next
+// message to deliver for that key, if any.
+func (q *keyQueues) pop(key string) (kafka.Message, bool) {
+	if key == "" {
+		return kafka.Message{}, false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	backlog := q.queues[key]
+	if len(backlog) == 0 {
+		delete(q.queues, key)
+		return kafka.Message{}, false
+	}
+	q.queues[key] = backlog[1:]
+	return backlog[0], true
+}
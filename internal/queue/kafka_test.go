@@ -0,0 +1,275 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// newTestKafka returns a Kafka broker for a fresh single-partition topic on
+// the cluster named by KAFKA_TEST_BROKERS, e.g. localhost:9092.
+func newTestKafka(t *testing.T) *Kafka {
+	t.Helper()
+
+	if os.Getenv("KAFKA_TEST_BROKERS") == "" {
+		t.Skip("KAFKA_TEST_BROKERS is not set")
+	}
+	brokers := strings.Split(os.Getenv("KAFKA_TEST_BROKERS"), ",")
+
+	name := fmt.Sprintf("%s-%d", strings.ReplaceAll(t.Name(), "/", "-"), time.Now().UnixNano())
+	opts := KafkaOptions{
+		Brokers:         brokers,
+		Topic:           name,
+		DeadLetterTopic: name + ".dead",
+		GroupID:         name,
+	}
+
+	client := &kafka.Client{Addr: kafka.TCP(brokers...)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := client.CreateTopics(ctx, &kafka.CreateTopicsRequest{
+		Topics: []kafka.TopicConfig{
+			{Topic: opts.Topic, NumPartitions: 1, ReplicationFactor: 1},
+			{Topic: opts.DeadLetterTopic, NumPartitions: 1, ReplicationFactor: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create topics: %v", err)
+	}
+	for topic, err := range resp.Errors {
+		if err != nil {
+			t.Fatalf("failed to create topic %s: %v", topic, err)
+		}
+	}
+
+	k, err := NewKafka(opts)
+	if err != nil {
+		t.Fatalf("failed to create kafka broker: %v", err)
+	}
+	t.Cleanup(k.Close)
+	return k
+}
+
+func publishKeyed(t *testing.T, k *Kafka, keyed ...string) {
+	t.Helper()
+
+	msgs := make([]Message, len(keyed))
+	for i, body := range keyed {
+		msgs[i] = Message{Key: body[:1], Body: []byte(body)}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for i, err := range k.Publish(ctx, msgs...) {
+		if err != nil {
+			t.Fatalf("failed to publish %s: %v", keyed[i], err)
+		}
+	}
+}
+
+// subscribe runs Subscribe until the test ends and returns its deliveries.
+func subscribe(t *testing.T, k *Kafka) <-chan Delivery {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	deliveries := make(chan Delivery, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := k.Subscribe(ctx, func(_ context.Context, d Delivery) {
+			deliveries <- d
+		}); err != nil {
+			t.Errorf("subscribe failed: %v", err)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return deliveries
+}
+
+func next(t *testing.T, deliveries <-chan Delivery) Delivery {
+	t.Helper()
+
+	select {
+	case d := <-deliveries:
+		return d
+	case <-time.After(30 * time.Second):
+		t.Fatal("timed out waiting for a delivery")
+		return Delivery{}
+	}
+}
+
+func committedOffset(t *testing.T, k *Kafka) int64 {
+	t.Helper()
+
+	client := &kafka.Client{Addr: kafka.TCP(k.opts.Brokers...)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: k.opts.GroupID,
+		Topics:  map[string][]int{k.opts.Topic: {0}},
+	})
+	if err != nil {
+		t.Fatalf("failed to fetch committed offset: %v", err)
+	}
+	partitions := resp.Topics[k.opts.Topic]
+	if len(partitions) != 1 || partitions[0].Error != nil {
+		t.Fatalf("unexpected offset fetch response: %+v", partitions)
+	}
+	return partitions[0].CommittedOffset
+}
+
+func waitForCommit(t *testing.T, k *Kafka, want int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		got := committedOffset(t, k)
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("committed offset = %d, want %d", got, want)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestKafkaRequeueKeepsKeyOrder(t *testing.T) {
+	k := newTestKafka(t)
+	publishKeyed(t, k, "a1", "b1", "a2", "b2", "a3")
+	deliveries := subscribe(t, k)
+
+	var (
+		got      = map[string][]string{}
+		requeued bool
+	)
+	for range 6 {
+		d := next(t, deliveries)
+		got[d.Key] = append(got[d.Key], string(d.Body))
+
+		if string(d.Body) == "a1" && !requeued {
+			requeued = true
+			if err := d.Nack(true); err != nil {
+				t.Fatalf("nack failed: %v", err)
+			}
+			continue
+		}
+		if err := d.Ack(); err != nil {
+			t.Fatalf("ack failed: %v", err)
+		}
+	}
+
+	if want := "a1 a1 a2 a3"; strings.Join(got["a"], " ") != want {
+		t.Errorf("key a delivered as %v, want %s", got["a"], want)
+	}
+	if want := "b1 b2"; strings.Join(got["b"], " ") != want {
+		t.Errorf("key b delivered as %v, want %s", got["b"], want)
+	}
+	waitForCommit(t, k, 5)
+}
+
+func TestKafkaSlowKeyDoesNotBlockOtherKeys(t *testing.T) {
+	k := newTestKafka(t)
+	publishKeyed(t, k, "a1", "a2", "b1", "b2", "b3")
+	deliveries := subscribe(t, k)
+
+	var held Delivery
+	for range 4 {
+		d := next(t, deliveries)
+		switch string(d.Body) {
+		case "a1":
+			held = d
+		case "a2":
+			t.Fatal("a2 was delivered before a1 was settled")
+		default:
+			if err := d.Ack(); err != nil {
+				t.Fatalf("ack failed: %v", err)
+			}
+		}
+	}
+	if held.Acknowledger == nil {
+		t.Fatal("a1 was not delivered")
+	}
+
+	// Nothing can be committed past the unsettled a1.
+	if got := committedOffset(t, k); got > 0 {
+		t.Fatalf("committed offset = %d while offset 0 is unsettled", got)
+	}
+
+	if err := held.Ack(); err != nil {
+		t.Fatalf("ack failed: %v", err)
+	}
+	if d := next(t, deliveries); string(d.Body) != "a2" {
+		t.Fatalf("got %s, want a2", d.Body)
+	} else if err := d.Ack(); err != nil {
+		t.Fatalf("ack failed: %v", err)
+	}
+	waitForCommit(t, k, 5)
+}
+
+func TestKafkaDeadLetterCommitsAfterWrite(t *testing.T) {
+	k := newTestKafka(t)
+	publishKeyed(t, k, "a1", "a2")
+	deliveries := subscribe(t, k)
+
+	d := next(t, deliveries)
+	if err := d.DeadLetter(context.Background(), map[string]any{"x-attempts": 3}); err != nil {
+		t.Fatalf("dead-letter failed: %v", err)
+	}
+	if d := next(t, deliveries); string(d.Body) != "a2" {
+		t.Fatalf("got %s, want a2", d.Body)
+	} else if err := d.Ack(); err != nil {
+		t.Fatalf("ack failed: %v", err)
+	}
+	waitForCommit(t, k, 2)
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   k.opts.Brokers,
+		Topic:     k.opts.DeadLetterTopic,
+		Partition: 0,
+	})
+	defer reader.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	m, err := reader.ReadMessage(ctx)
+	if err != nil {
+		t.Fatalf("failed to read dead letter: %v", err)
+	}
+	headers := kafkaHeaders(m.Headers)
+	if string(m.Value) != "a1" || headers["x-original-topic"] != k.opts.Topic || headers["x-attempts"] != "3" {
+		t.Fatalf("unexpected dead letter %s with headers %v", m.Value, headers)
+	}
+}
+
+func TestKeyQueuesReleaseInOrder(t *testing.T) {
+	q := newKeyQueues()
+	msg := func(key string, offset int64) kafka.Message {
+		return kafka.Message{Key: []byte(key), Offset: offset}
+	}
+
+	if !q.push(msg("a", 0)) || q.push(msg("a", 1)) || q.push(msg("a", 2)) {
+		t.Fatal("only the first message of a key may be delivered immediately")
+	}
+	if !q.push(msg("", 3)) || !q.push(msg("", 4)) {
+		t.Fatal("unkeyed messages must never wait")
+	}
+	for _, want := range []int64{1, 2} {
+		m, ok := q.pop("a")
+		if !ok || m.Offset != want {
+			t.Fatalf("pop = %d, %v; want %d", m.Offset, ok, want)
+		}
+	}
+	if _, ok := q.pop("a"); ok {
+		t.Fatal("key a should be idle")
+	}
+	if !q.push(msg("a", 5)) {
+		t.Fatal("an idle key must deliver immediately")
+	}
+}
@@ -0,0 +1,233 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Memory is an in-process broker with the same delivery semantics as the
+// RabbitMQ queue: messages are delivered to one subscriber at a time, stay
+// unacknowledged until settled, are redelivered when requeued or when their
+// subscriber stops, and are dead-lettered when rejected without requeue.
+type Memory struct {
+	mu          sync.Mutex
+	ready       []*memoryMessage
+	deadLetters []Message
+	wake        chan struct{}
+	closed      bool
+}
+
+type memoryMessage struct {
+	Message
+	deliveries int
+}
+
+var (
+	_ Publisher  = (*Memory)(nil)
+	_ Subscriber = (*Memory)(nil)
+)
+
+func NewMemory() *Memory {
+	return &Memory{
+		wake: make(chan struct{}),
+	}
+}
+
+func (m *Memory) Publish(ctx context.Context, msgs ...Message) []error {
+	errs := make([]error, len(msgs))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, msg := range msgs {
+		if m.closed {
+			errs[i] = ErrClosed
+			continue
+		}
+		m.ready = append(m.ready, &memoryMessage{Message: copyMessage(msg)})
+	}
+	m.signal()
+
+	return errs
+}
+
+func (m *Memory) Subscribe(ctx context.Context, handler Handler) error {
+	var (
+		mu       sync.Mutex
+		inflight = make(map[*memoryAcknowledger]struct{})
+	)
+	// Unsettled deliveries go back to the queue when the subscriber stops,
+	// like RabbitMQ does when a consumer's channel closes.
+	defer func() {
+		mu.Lock()
+		unsettled := make([]*memoryAcknowledger, 0, len(inflight))
+		for a := range inflight {
+			unsettled = append(unsettled, a)
+		}
+		mu.Unlock()
+
+		for _, a := range unsettled {
+			a.settle(func() { m.requeue(a.msg) })
+		}
+	}()
+
+	for {
+		msg, err := m.next(ctx)
+		if err != nil {
+			if errors.Is(err, ErrClosed) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		a := &memoryAcknowledger{broker: m, msg: msg}
+		a.onSettle = func() {
+			mu.Lock()
+			delete(inflight, a)
+			mu.Unlock()
+		}
+		mu.Lock()
+		inflight[a] = struct{}{}
+		mu.Unlock()
+
+		handler(ctx, Delivery{
+			Message:      copyMessage(msg.Message),
+			Acknowledger: a,
+		})
+	}
+}
+
+// DeadLetters returns the messages that were dead-lettered so far.
+func (m *Memory) DeadLetters() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.deadLetters...)
+}
+
+// Len reports how many messages are waiting to be delivered.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.ready)
+}
+
+func (m *Memory) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.closed {
+		m.closed = true
+		m.signal()
+	}
+}
+
+func (m *Memory) next(ctx context.Context) (*memoryMessage, error) {
+	for {
+		m.mu.Lock()
+		if m.closed {
+			m.mu.Unlock()
+			return nil, ErrClosed
+		}
+		if len(m.ready) > 0 {
+			msg := m.ready[0]
+			m.ready = m.ready[1:]
+			msg.deliveries++
+			m.mu.Unlock()
+			return msg, nil
+		}
+		wake := m.wake
+		m.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+func (m *Memory) requeue(msg *memoryMessage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ready = append(m.ready, msg)
+	m.signal()
+}
+
+func (m *Memory) deadLetter(msg *memoryMessage, headers map[string]any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dead := copyMessage(msg.Message)
+	for k, v := range headers {
+		dead.Headers[k] = v
+	}
+	dead.Headers["x-delivery-count"] = msg.deliveries
+	m.deadLetters = append(m.deadLetters, dead)
+}
+
+// signal wakes every waiting subscriber. It must be called with mu held.
+func (m *Memory) signal() {
+	close(m.wake)
+	m.wake = make(chan struct{})
+}
+
+type memoryAcknowledger struct {
+	broker   *Memory
+	msg      *memoryMessage
+	once     sync.Once
+	onSettle func()
+}
+
+var errAlreadySettled = errors.New("delivery was already settled")
+
+func (a *memoryAcknowledger) settle(fn func()) error {
+	settled := false
+	a.once.Do(func() {
+		settled = true
+		fn()
+		if a.onSettle != nil {
+			a.onSettle()
+		}
+	})
+	if !settled {
+		return errAlreadySettled
+	}
+	return nil
+}
+
+func (a *memoryAcknowledger) Ack() error {
+	return a.settle(func() {})
+}
+
+func (a *memoryAcknowledger) Nack(requeue bool) error {
+	return a.settle(func() {
+		if requeue {
+			a.broker.requeue(a.msg)
+		} else {
+			a.broker.deadLetter(a.msg, nil)
+		}
+	})
+}
+
+func (a *memoryAcknowledger) DeadLetter(ctx context.Context, headers map[string]any) error {
+	return a.settle(func() {
+		a.broker.deadLetter(a.msg, headers)
+	})
+}
+
+func copyMessage(msg Message) Message {
+	headers := make(map[string]any, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	return Message{
+		Key:     msg.Key,
+		Subject: msg.Subject,
+		Body:    append([]byte(nil), msg.Body...),
+		Headers: headers,
+	}
+}
@@ -0,0 +1,323 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATS is the NATS JetStream Publisher and Subscriber. Messages are stored
+// under SubjectPrefix.{Message.Subject}, e.g. webhooks.stripe.invoice.paid,
+// and consumed by a durable pull consumer with explicit acks. While a
+// message is being processed its AckWait is extended with InProgress, so
+// long retry backoffs do not cause a redelivery.
+type NATS struct {
+	opts NATSOptions
+	conn *nats.Conn
+	js   jetstream.JetStream
+	// closed is closed once the connection has finished draining.
+	closed chan struct{}
+}
+
+type NATSOptions struct {
+	URL           string
+	Stream        string
+	SubjectPrefix string
+	// DeadLetterStream stores messages under DeadLetterPrefix.{subject} once
+	// they are rejected without requeue or run out of deliveries.
+	DeadLetterStream string
+	DeadLetterPrefix string
+	Durable          string
+	// FilterSubjects limits the durable consumer to matching subjects, e.g.
+	// webhooks.stripe.invoice.>. Empty consumes the whole stream.
+	FilterSubjects []string
+	// MaxDeliver caps how many times a message is delivered before it is
+	// dead-lettered instead of redelivered.
+	MaxDeliver    int
+	MaxAckPending int
+	// AckWait is how long the server waits for an ack or an InProgress
+	// heartbeat before redelivering a message.
+	AckWait time.Duration
+}
+
+var (
+	_ Publisher  = (*NATS)(nil)
+	_ Subscriber = (*NATS)(nil)
+)
+
+func NewNATS(ctx context.Context, opts NATSOptions) (*NATS, error) {
+	if opts.AckWait <= 0 {
+		return nil, errors.New("nats ack wait must be positive")
+	}
+
+	closed := make(chan struct{})
+	conn, err := nats.Connect(opts.URL,
+		nats.MaxReconnects(-1),
+		nats.ClosedHandler(func(*nats.Conn) { close(closed) }),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	n := &NATS{opts: opts, conn: conn, js: js, closed: closed}
+	if err := n.setup(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return n, nil
+}
+
+// setup declares the relay and dead-letter streams.
+func (n *NATS) setup(ctx context.Context) error {
+	if _, err := n.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     n.opts.Stream,
+		Subjects: []string{n.opts.SubjectPrefix + ".>"},
+		Storage:  jetstream.FileStorage,
+	}); err != nil {
+		return fmt.Errorf("failed to declare stream: %w", err)
+	}
+
+	if n.opts.DeadLetterStream == "" {
+		return nil
+	}
+	if _, err := n.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     n.opts.DeadLetterStream,
+		Subjects: []string{n.opts.DeadLetterPrefix + ".>"},
+		Storage:  jetstream.FileStorage,
+	}); err != nil {
+		return fmt.Errorf("failed to declare dead-letter stream: %w", err)
+	}
+	return nil
+}
+
+// Publish publishes every message asynchronously and then waits for the
+// stream to acknowledge them.
+func (n *NATS) Publish(ctx context.Context, msgs ...Message) []error {
+	errs := make([]error, len(msgs))
+	futures := make([]jetstream.PubAckFuture, len(msgs))
+
+	for i, msg := range msgs {
+		future, err := n.js.PublishMsgAsync(&nats.Msg{
+			Subject: natsSubject(n.opts.SubjectPrefix, msg.Subject),
+			Header:  natsHeader(msg.Headers),
+			Data:    msg.Body,
+		})
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to publish message: %w", err)
+			continue
+		}
+		futures[i] = future
+	}
+
+	for i, future := range futures {
+		if future == nil {
+			continue
+		}
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			errs[i] = fmt.Errorf("failed to publish message: %w", err)
+		case <-ctx.Done():
+			errs[i] = fmt.Errorf("failed to wait for publish ack: %w", ctx.Err())
+		}
+	}
+
+	return errs
+}
+
+// Subscribe creates or updates the durable consumer and delivers messages to
+// handler until ctx is done or the connection is closed.
+func (n *NATS) Subscribe(ctx context.Context, handler Handler) error {
+	cons, err := n.js.CreateOrUpdateConsumer(ctx, n.opts.Stream, jetstream.ConsumerConfig{
+		Durable:        n.opts.Durable,
+		AckPolicy:      jetstream.AckExplicitPolicy,
+		AckWait:        n.opts.AckWait,
+		MaxDeliver:     n.opts.MaxDeliver,
+		MaxAckPending:  n.opts.MaxAckPending,
+		FilterSubjects: n.opts.FilterSubjects,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create consumer: %w", err)
+	}
+
+	it, err := cons.Messages()
+	if err != nil {
+		return fmt.Errorf("failed to consume messages: %w", err)
+	}
+	stop := context.AfterFunc(ctx, it.Stop)
+	defer stop()
+
+	for {
+		msg, err := it.Next()
+		if err != nil {
+			if errors.Is(err, jetstream.ErrMsgIteratorClosed) || n.conn.IsClosed() {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch message: %w", err)
+		}
+
+		var attempt int
+		if md, err := msg.Metadata(); err == nil {
+			attempt = int(md.NumDelivered)
+		}
+
+		ack := &natsAcknowledger{broker: n, msg: msg, done: make(chan struct{})}
+		go ack.heartbeat()
+		handler(ctx, Delivery{
+			Message: Message{
+				Subject: strings.TrimPrefix(msg.Subject(), n.opts.SubjectPrefix+"."),
+				Body:    msg.Data(),
+				Headers: natsHeaders(msg.Headers()),
+			},
+			Acknowledger: ack,
+			Attempt:      attempt,
+		})
+	}
+}
+
+// Close drains the connection and waits until pending acks and publishes
+// have been flushed and the connection is closed.
+func (n *NATS) Close() {
+	if err := n.conn.Drain(); err != nil {
+		if !errors.Is(err, nats.ErrConnectionClosed) {
+			fmt.Println("Queue close error:", err)
+		}
+		return
+	}
+	<-n.closed
+}
+
+// DeadLetter republishes msg to the dead-letter stream with headers
+// describing the failure. The caller settles the original delivery.
+func (n *NATS) DeadLetter(ctx context.Context, msg jetstream.Msg, headers map[string]any) error {
+	if n.opts.DeadLetterStream == "" {
+		return errors.New("no dead-letter stream configured")
+	}
+
+	merged := natsHeaders(msg.Headers())
+	for k, v := range headers {
+		merged[k] = v
+	}
+	merged["x-original-subject"] = msg.Subject()
+	if md, err := msg.Metadata(); err == nil {
+		merged["x-delivery-count"] = md.NumDelivered
+	}
+
+	subject := strings.TrimPrefix(msg.Subject(), n.opts.SubjectPrefix+".")
+	if _, err := n.js.PublishMsg(ctx, &nats.Msg{
+		Subject: natsSubject(n.opts.DeadLetterPrefix, subject),
+		Header:  natsHeader(merged),
+		Data:    msg.Data(),
+	}); err != nil {
+		return fmt.Errorf("failed to dead-letter message: %w", err)
+	}
+	return nil
+}
+
+type natsAcknowledger struct {
+	broker *NATS
+	msg    jetstream.Msg
+	// done stops the heartbeat once the message is settled.
+	done chan struct{}
+	once sync.Once
+}
+
+// heartbeat tells the server the message is still in progress every third
+// of AckWait until it is settled.
+func (a *natsAcknowledger) heartbeat() {
+	ticker := time.NewTicker(a.broker.opts.AckWait / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			if err := a.msg.InProgress(); err != nil {
+				if a.broker.conn.IsClosed() {
+					return
+				}
+				log.Printf("Failed to extend ack wait: %v", err)
+			}
+		}
+	}
+}
+
+func (a *natsAcknowledger) stop() {
+	a.once.Do(func() { close(a.done) })
+}
+
+func (a *natsAcknowledger) Ack() error {
+	a.stop()
+	return a.msg.Ack()
+}
+
+// Nack redelivers the message when requeue is set. The server stops
+// redelivering once MaxDeliver is reached, so a caller that requeues should
+// dead-letter the message on its last attempt (see Delivery.Attempt) instead.
+func (a *natsAcknowledger) Nack(requeue bool) error {
+	a.stop()
+	if requeue {
+		return a.msg.Nak()
+	}
+	return a.DeadLetter(context.Background(), nil)
+}
+
+func (a *natsAcknowledger) DeadLetter(ctx context.Context, headers map[string]any) error {
+	a.stop()
+	if err := a.broker.DeadLetter(ctx, a.msg, headers); err != nil {
+		// Leave the message to be redelivered rather than dropping it.
+		return errors.Join(err, a.msg.Nak())
+	}
+	return a.msg.Term()
+}
+
+// natsSubject joins prefix and subject, replacing characters that are not
+// allowed in subject tokens so that provider and event type names such as
+// "invoice.paid" map onto wildcard-friendly subjects.
+func natsSubject(prefix, subject string) string {
+	tokens := strings.Split(subject, ".")
+	for i, token := range tokens {
+		token = strings.Map(func(r rune) rune {
+			switch r {
+			case '*', '>', ' ', '\t', '\r', '\n':
+				return '_'
+			}
+			return r
+		}, token)
+		if token == "" {
+			token = "_"
+		}
+		tokens[i] = token
+	}
+	return prefix + "." + strings.Join(tokens, ".")
+}
+
+func natsHeader(headers map[string]any) nats.Header {
+	out := make(nats.Header, len(headers))
+	for k, v := range headers {
+		out.Set(k, fmt.Sprint(v))
+	}
+	return out
+}
+
+func natsHeaders(headers nats.Header) map[string]any {
+	out := make(map[string]any, len(headers))
+	for k := range headers {
+		out[k] = headers.Get(k)
+	}
+	return out
+}
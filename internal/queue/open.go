@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/petechu/idempotent-webhook-relay/internal/config"
+)
+
+const (
+	BrokerRabbitMQ = "rabbitmq"
+	BrokerKafka    = "kafka"
+	BrokerNATS     = "nats"
+	BrokerRedis    = "redis"
+	// BrokerPostgres has no broker: consumers claim events straight from the
+	// outbox table, so Open rejects it.
+	BrokerPostgres = "postgres"
+	BrokerMemory   = "memory"
+)
+
+type Broker interface {
+	Publisher
+	Subscriber
+}
+
+// Open connects to the broker selected by cfg.Broker.
+func Open(ctx context.Context, cfg *config.Config) (Broker, error) {
+	prefetch := cfg.ConsumerPrefetch
+	if prefetch <= 0 {
+		prefetch = cfg.ConsumerWorkers
+	}
+
+	switch cfg.Broker {
+	case BrokerRabbitMQ:
+		q, err := NewQueue(
+			ctx,
+			cfg.RabbitMQURL,
+			WithName(cfg.RabbitMQQueue),
+			WithPublisherConfirms(),
+			WithPrefetch(prefetch),
+			WithDeadLetter(cfg.RabbitMQDeadLetterExchange, cfg.RabbitMQDeadLetterQueue),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return q, nil
+	case BrokerKafka:
+		k, err := NewKafka(KafkaOptions{
+			Brokers:         cfg.KafkaBrokers,
+			Topic:           cfg.KafkaTopic,
+			DeadLetterTopic: cfg.KafkaDeadLetterTopic,
+			GroupID:         cfg.KafkaGroupID,
+			MaxPending:      cfg.KafkaMaxPending,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return k, nil
+	case BrokerNATS:
+		n, err := NewNATS(ctx, NATSOptions{
+			URL:              cfg.NATSURL,
+			Stream:           cfg.NATSStream,
+			SubjectPrefix:    cfg.NATSSubjectPrefix,
+			DeadLetterStream: cfg.NATSDeadLetterStream,
+			DeadLetterPrefix: cfg.NATSDeadLetterSubjectPrefix,
+			Durable:          cfg.NATSDurable,
+			FilterSubjects:   cfg.NATSFilterSubjects,
+			// The first delivery plus one redelivery per consumer retry.
+			MaxDeliver:    cfg.ConsumerMaxRetries + 1,
+			MaxAckPending: prefetch,
+			AckWait:       cfg.NATSAckWait,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	case BrokerRedis:
+		consumer := cfg.RedisConsumer
+		if consumer == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				hostname = "consumer"
+			}
+			consumer = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+		}
+
+		r, err := NewRedis(ctx, RedisOptions{
+			URL:              cfg.RedisURL,
+			Stream:           cfg.RedisStream,
+			DeadLetterStream: cfg.RedisDeadLetterStream,
+			DeadLetterMaxLen: cfg.RedisDeadLetterMaxLen,
+			Group:            cfg.RedisGroup,
+			Consumer:         consumer,
+			Count:            int64(prefetch),
+			ClaimMinIdle:     cfg.RedisClaimMinIdle,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	case BrokerMemory:
+		return NewMemory(), nil
+	case BrokerPostgres:
+		return nil, fmt.Errorf("the %s transport does not use a broker", cfg.Broker)
+	default:
+		return nil, fmt.Errorf("unknown broker %q", cfg.Broker)
+	}
+}
@@ -4,16 +4,57 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/rabbitmq/amqp091-go"
 )
 
+var ErrClosed = errors.New("queue is closed")
+
+// State reports the health of the supervised broker connection.
+type State int32
+
+const (
+	StateConnecting State = iota
+	StateConnected
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// Queue is the RabbitMQ Publisher and Subscriber. It supervises its
+// connection and channel: when either closes it is reopened with backoff,
+// the topology is re-declared and subscriptions resume on the new channel.
 type Queue struct {
-	amqp091.Queue
-	Context    context.Context
-	Connection *amqp091.Connection
-	Channel    *amqp091.Channel
-	Close      func()
+	Name    string
+	Context context.Context
+
+	url  string
+	opts Options
+
+	mu    sync.RWMutex
+	conn  *amqp091.Connection
+	ch    *amqp091.Channel
+	ready chan struct{}
+
+	state     atomic.Int32
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 type Options struct {
@@ -23,24 +64,26 @@ type Options struct {
 	Exclusive  bool
 	NoWait     bool
 	Args       amqp091.Table
+	// Confirm puts the channel into publisher confirm mode so that Publish
+	// and PublishBatch only succeed once the broker has acked the message.
+	Confirm bool
+	// Prefetch limits how many unacknowledged deliveries the broker sends to
+	// each consumer. Zero means unlimited.
+	Prefetch int
+	// DeadLetterExchange, when set, is declared as a fanout exchange bound to
+	// DeadLetterQueue and used as the queue's x-dead-letter-exchange.
+	DeadLetterExchange string
+	DeadLetterQueue    string
 }
 
 type Option func(*Options)
 
 func NewQueue(ctx context.Context, url string, opts ...Option) (*Queue, error) {
-	conn, err := amqp091.Dial(url)
-	if err != nil {
-		return nil, err
-	}
-
-	ch, err := conn.Channel()
-	if err != nil {
-		return nil, err
-	}
-
+	// The queue is durable and messages are published as persistent, so a
+	// confirmed message survives a broker restart.
 	cfg := Options{
 		Name:       "default",
-		Durable:    false,
+		Durable:    true,
 		AutoDelete: false,
 		Exclusive:  false,
 		NoWait:     false,
@@ -50,45 +93,373 @@ func NewQueue(ctx context.Context, url string, opts ...Option) (*Queue, error) {
 		opt(&cfg)
 	}
 
-	q, err := ch.QueueDeclare(
-		cfg.Name,
-		cfg.Durable,
-		cfg.AutoDelete,
-		cfg.Exclusive,
-		cfg.NoWait,
-		cfg.Args,
-	)
-	if err != nil {
+	q := &Queue{
+		Name:    cfg.Name,
+		Context: ctx,
+		url:     url,
+		opts:    cfg,
+		ready:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if err := q.connect(); err != nil {
 		return nil, err
 	}
 
-	return &Queue{
-		Context:    ctx,
-		Queue:      q,
-		Connection: conn,
-		Channel:    ch,
-		Close: func() {
-			var errs error
-			if err := ch.Close(); err != nil {
-				errs = errors.Join(errs, fmt.Errorf("failed to close channel: %w", err))
+	go q.supervise()
+
+	return q, nil
+}
+
+func (q *Queue) State() State {
+	return State(q.state.Load())
+}
+
+func (q *Queue) IsConnected() bool {
+	return q.State() == StateConnected
+}
+
+func (q *Queue) Close() {
+	q.closeOnce.Do(func() {
+		close(q.done)
+		q.state.Store(int32(StateClosed))
+
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		var errs error
+		if err := q.ch.Close(); err != nil && !errors.Is(err, amqp091.ErrClosed) {
+			errs = errors.Join(errs, fmt.Errorf("failed to close channel: %w", err))
+		}
+		if err := q.conn.Close(); err != nil && !errors.Is(err, amqp091.ErrClosed) {
+			errs = errors.Join(errs, fmt.Errorf("failed to close connection: %w", err))
+		}
+		if errs != nil {
+			fmt.Println("Queue close error:", errs)
+		}
+	})
+}
+
+// connect opens a channel and declares the topology. The current connection
+// is reused while it is still open, e.g. after a channel-level exception, and
+// re-dialled otherwise.
+func (q *Queue) connect() error {
+	q.mu.RLock()
+	conn := q.conn
+	q.mu.RUnlock()
+
+	dialled := conn == nil || conn.IsClosed()
+	if dialled {
+		var err error
+		if conn, err = amqp091.Dial(q.url); err != nil {
+			return err
+		}
+	}
+	closeConn := func() {
+		if dialled {
+			conn.Close()
+		}
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		closeConn()
+		return err
+	}
+
+	if err := q.setup(ch); err != nil {
+		ch.Close()
+		closeConn()
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		ch.Close()
+		closeConn()
+		return ErrClosed
+	default:
+	}
+
+	if q.ch != nil && q.ch != ch {
+		q.ch.Close()
+	}
+	q.conn = conn
+	q.ch = ch
+	close(q.ready)
+
+	q.state.Store(int32(StateConnected))
+	return nil
+}
+
+// setup declares the topology on a freshly opened channel.
+func (q *Queue) setup(ch *amqp091.Channel) error {
+	if q.opts.Prefetch > 0 {
+		if err := ch.Qos(q.opts.Prefetch, 0, false); err != nil {
+			return fmt.Errorf("failed to set prefetch: %w", err)
+		}
+	}
+
+	if q.opts.Confirm {
+		if err := ch.Confirm(false); err != nil {
+			return fmt.Errorf("failed to enable publisher confirms: %w", err)
+		}
+	}
+
+	args := q.opts.Args
+	if q.opts.DeadLetterExchange != "" {
+		if err := q.setupDeadLetter(ch); err != nil {
+			return err
+		}
+		args = amqp091.Table{}
+		for k, v := range q.opts.Args {
+			args[k] = v
+		}
+		args["x-dead-letter-exchange"] = q.opts.DeadLetterExchange
+	}
+
+	if _, err := ch.QueueDeclare(
+		q.opts.Name,
+		q.opts.Durable,
+		q.opts.AutoDelete,
+		q.opts.Exclusive,
+		q.opts.NoWait,
+		args,
+	); err != nil {
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+	return nil
+}
+
+func (q *Queue) setupDeadLetter(ch *amqp091.Channel) error {
+	if err := ch.ExchangeDeclare(q.opts.DeadLetterExchange, amqp091.ExchangeFanout, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter exchange: %w", err)
+	}
+	if _, err := ch.QueueDeclare(q.opts.DeadLetterQueue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
+	if err := ch.QueueBind(q.opts.DeadLetterQueue, "", q.opts.DeadLetterExchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind dead-letter queue: %w", err)
+	}
+	return nil
+}
+
+func (q *Queue) supervise() {
+	for {
+		q.mu.RLock()
+		connClosed := q.conn.NotifyClose(make(chan *amqp091.Error, 1))
+		chClosed := q.ch.NotifyClose(make(chan *amqp091.Error, 1))
+		q.mu.RUnlock()
+
+		// A channel-level exception, e.g. a failed publish, closes only the
+		// channel; without a new one every publish and consume would fail.
+		select {
+		case <-q.done:
+			return
+		case err := <-connClosed:
+			log.Printf("Queue connection lost: %v; reconnecting", err)
+		case err := <-chClosed:
+			log.Printf("Queue channel closed: %v; reopening", err)
+		}
+
+		q.mu.Lock()
+		q.ready = make(chan struct{})
+		q.mu.Unlock()
+		q.state.Store(int32(StateConnecting))
+
+		for attempt := 0; ; attempt++ {
+			delay := min(time.Duration(math.Pow(2, float64(attempt)))*time.Second, 30*time.Second)
+			select {
+			case <-q.done:
+				return
+			case <-time.After(delay):
 			}
-			if err := conn.Close(); err != nil {
-				errs = errors.Join(errs, fmt.Errorf("failed to close connection: %w", err))
+
+			if err := q.connect(); err != nil {
+				log.Printf("Queue reconnect attempt %d failed: %v", attempt+1, err)
+				continue
 			}
-			if errs != nil {
-				fmt.Println("Queue close error:", errs)
+			log.Printf("Queue reconnected after %d attempt(s)", attempt+1)
+			break
+		}
+	}
+}
+
+// channel waits until the connection is up and returns the current channel.
+func (q *Queue) channel(ctx context.Context) (*amqp091.Channel, error) {
+	q.mu.RLock()
+	ready := q.ready
+	q.mu.RUnlock()
+
+	select {
+	case <-q.done:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-ready:
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.ch, nil
+}
+
+var (
+	_ Publisher  = (*Queue)(nil)
+	_ Subscriber = (*Queue)(nil)
+)
+
+// Subscribe delivers messages to handler with manual acknowledgements until
+// ctx is done or the queue is closed, transparently re-registering the
+// consumer after a reconnect.
+func (q *Queue) Subscribe(ctx context.Context, handler Handler) error {
+	for {
+		ch, err := q.channel(ctx)
+		if err != nil {
+			if errors.Is(err, ErrClosed) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		deliveries, err := ch.Consume(q.Name, "", false, false, false, false, nil)
+		if err != nil {
+			log.Printf("Failed to register a consumer: %v", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-q.done:
+				return nil
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		for d := range deliveries {
+			handler(ctx, Delivery{
+				Message: Message{
+					Body:    d.Body,
+					Headers: d.Headers,
+				},
+				Acknowledger: &rabbitAcknowledger{queue: q, delivery: d},
+			})
+			if ctx.Err() != nil {
+				return nil
 			}
-		},
-	}, nil
+		}
+	}
+}
+
+// Publish publishes every message and then waits for the broker to confirm
+// them. Without confirm mode a message counts as acked once written. While
+// the connection is being re-established it waits until ctx is done.
+func (q *Queue) Publish(ctx context.Context, msgs ...Message) []error {
+	errs := make([]error, len(msgs))
+	confirms := make([]*amqp091.DeferredConfirmation, len(msgs))
+
+	ch, err := q.channel(ctx)
+	if err != nil {
+		for i := range errs {
+			errs[i] = fmt.Errorf("failed to publish message: %w", err)
+		}
+		return errs
+	}
+
+	for i, msg := range msgs {
+		confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", q.Name, false, false, amqp091.Publishing{
+			Headers:      msg.Headers,
+			DeliveryMode: amqp091.Persistent,
+			ContentType:  "text/plain",
+			Body:         msg.Body,
+		})
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to publish message: %w", err)
+			continue
+		}
+		confirms[i] = confirm
+	}
+
+	for i, confirm := range confirms {
+		if confirm == nil {
+			continue
+		}
+		acked, err := confirm.WaitContext(ctx)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to wait for publisher confirm: %w", err)
+		} else if !acked {
+			errs[i] = errors.New("message was nacked by the broker")
+		}
+	}
+
+	return errs
+}
+
+type rabbitAcknowledger struct {
+	queue    *Queue
+	delivery amqp091.Delivery
 }
 
-func (q *Queue) Publish(body []byte) error {
-	if err := q.Channel.PublishWithContext(q.Context, "", q.Name, false, false, amqp091.Publishing{
+func (a *rabbitAcknowledger) Ack() error {
+	return a.delivery.Ack(false)
+}
+
+func (a *rabbitAcknowledger) Nack(requeue bool) error {
+	return a.delivery.Nack(false, requeue)
+}
+
+// DeadLetter only acks the original once the broker has confirmed the copy on
+// the dead-letter exchange.
+func (a *rabbitAcknowledger) DeadLetter(ctx context.Context, headers map[string]any) error {
+	if err := a.queue.DeadLetter(ctx, a.delivery, headers); err != nil {
+		// Rejecting still routes the message through x-dead-letter-exchange.
+		return errors.Join(err, a.delivery.Nack(false, false))
+	}
+	return a.delivery.Ack(false)
+}
+
+// DeadLetter republishes msg to the dead-letter exchange with headers
+// describing the failure and, in confirm mode, waits until the broker has
+// confirmed it. The caller settles the original delivery.
+func (q *Queue) DeadLetter(ctx context.Context, msg amqp091.Delivery, headers map[string]any) error {
+	if q.opts.DeadLetterExchange == "" {
+		return errors.New("no dead-letter exchange configured")
+	}
+
+	ch, err := q.channel(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter message: %w", err)
+	}
+
+	merged := amqp091.Table{}
+	for k, v := range msg.Headers {
+		merged[k] = v
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	merged["x-original-queue"] = q.Name
+
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, q.opts.DeadLetterExchange, "", false, false, amqp091.Publishing{
+		Headers:      merged,
 		DeliveryMode: amqp091.Persistent,
-		ContentType:  "text/plain",
-		Body:         body,
-	}); err != nil {
-		return fmt.Errorf("failed to publish message: %w", err)
+		ContentType:  msg.ContentType,
+		Body:         msg.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter message: %w", err)
+	}
+	if confirm == nil {
+		return nil
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for dead-letter confirm: %w", err)
+	}
+	if !acked {
+		return errors.New("dead-lettered message was nacked by the broker")
 	}
 	return nil
 }
@@ -105,5 +476,36 @@ func WithConfig(opts Options) Option {
 		o.Exclusive = opts.Exclusive
 		o.NoWait = opts.NoWait
 		o.Args = opts.Args
+		o.Confirm = opts.Confirm
+		o.Prefetch = opts.Prefetch
+		o.DeadLetterExchange = opts.DeadLetterExchange
+		o.DeadLetterQueue = opts.DeadLetterQueue
+	}
+}
+
+func WithName(name string) Option {
+	return func(o *Options) {
+		if name != "" {
+			o.Name = name
+		}
+	}
+}
+
+func WithPublisherConfirms() Option {
+	return func(o *Options) {
+		o.Confirm = true
+	}
+}
+
+func WithPrefetch(count int) Option {
+	return func(o *Options) {
+		o.Prefetch = count
+	}
+}
+
+func WithDeadLetter(exchange, queue string) Option {
+	return func(o *Options) {
+		o.DeadLetterExchange = exchange
+		o.DeadLetterQueue = queue
 	}
 }
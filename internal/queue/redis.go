@@ -0,0 +1,420 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is the Redis Streams Publisher and Subscriber. Messages are appended
+// to Stream and read through a consumer group; entries stay pending until
+// they are acknowledged with XACK, and entries left pending by a crashed
+// consumer are taken over with XAUTOCLAIM once they have been idle for
+// ClaimMinIdle. A live consumer keeps resetting the idle time of the entries
+// it is still processing so that they are not claimed from under it.
+//
+// Settled entries are deleted from Stream, so it only holds messages that are
+// waiting or in progress; the group must therefore be the stream's only
+// reader. DeadLetterStream is trimmed to roughly DeadLetterMaxLen entries.
+type Redis struct {
+	opts   RedisOptions
+	client *redis.Client
+}
+
+type RedisOptions struct {
+	URL    string
+	Stream string
+	// DeadLetterStream receives messages that are rejected without requeue or
+	// dead-lettered by the consumer.
+	DeadLetterStream string
+	// DeadLetterMaxLen caps DeadLetterStream, dropping the oldest entries.
+	// Zero keeps every entry.
+	DeadLetterMaxLen int64
+	Group            string
+	// Consumer names this process within Group and must be unique per
+	// replica.
+	Consumer string
+	// Count is how many entries are read per XREADGROUP or XAUTOCLAIM call.
+	Count int64
+	// ClaimMinIdle is how long an entry must go without a heartbeat from
+	// its consumer before another consumer claims it.
+	ClaimMinIdle time.Duration
+}
+
+const (
+	redisBodyField    = "body"
+	redisKeyField     = "key"
+	redisSubjectField = "subject"
+	redisHeadersField = "headers"
+)
+
+var (
+	_ Publisher  = (*Redis)(nil)
+	_ Subscriber = (*Redis)(nil)
+)
+
+// redisRefreshScript resets the idle time of the entries in ARGV[3:] that
+// are still owned by consumer ARGV[2] of group ARGV[1] and returns those that
+// are not. Checking the owner first keeps a slow consumer from taking an
+// entry back after another consumer has claimed it.
+var redisRefreshScript = redis.NewScript(`
+local lost = {}
+for i = 3, #ARGV do
+	local pending = redis.call('XPENDING', KEYS[1], ARGV[1], ARGV[i], ARGV[i], 1)
+	if #pending > 0 and pending[1][2] == ARGV[2] then
+		redis.call('XCLAIM', KEYS[1], ARGV[1], ARGV[2], 0, ARGV[i], 'JUSTID')
+	else
+		table.insert(lost, ARGV[i])
+	end
+end
+return lost
+`)
+
+func NewRedis(ctx context.Context, opts RedisOptions) (*Redis, error) {
+	if opts.ClaimMinIdle <= 0 {
+		return nil, errors.New("redis claim min idle must be positive")
+	}
+
+	redisOpts, err := redis.ParseURL(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis url: %w", err)
+	}
+
+	client := redis.NewClient(redisOpts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &Redis{opts: opts, client: client}, nil
+}
+
+// Publish appends every message to the stream in a single pipeline.
+func (r *Redis) Publish(ctx context.Context, msgs ...Message) []error {
+	errs := make([]error, len(msgs))
+	if len(msgs) == 0 {
+		return errs
+	}
+
+	cmds := make([]*redis.StringCmd, len(msgs))
+	pipe := r.client.Pipeline()
+	for i, msg := range msgs {
+		values, err := redisValues(msg)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to publish message: %w", err)
+			continue
+		}
+		cmds[i] = pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: r.opts.Stream,
+			Values: values,
+		})
+	}
+	// Per-command errors are reported below.
+	_, _ = pipe.Exec(ctx)
+
+	for i, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		if err := cmd.Err(); err != nil {
+			errs[i] = fmt.Errorf("failed to publish message: %w", err)
+		}
+	}
+	return errs
+}
+
+// Subscribe joins the consumer group and delivers messages to handler until
+// ctx is done or the client is closed. Before each read it reclaims entries
+// that other consumers left pending for longer than ClaimMinIdle, and until
+// an entry is settled it refreshes the entry's idle time every third of
+// ClaimMinIdle.
+func (r *Redis) Subscribe(ctx context.Context, handler Handler) error {
+	err := r.client.XGroupCreateMkStream(ctx, r.opts.Stream, r.opts.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	inFlight := newRedisInFlight()
+	go r.refresh(ctx, inFlight)
+
+	deliver := func(entries []redis.XMessage) {
+		// Track the whole batch first: entries still waiting for the handler
+		// are pending too.
+		for _, entry := range entries {
+			inFlight.add(entry.ID)
+		}
+		for _, entry := range entries {
+			msg, err := redisMessage(entry)
+			if err != nil {
+				log.Printf("Failed to decode stream entry %s: %v", entry.ID, err)
+			}
+			handler(ctx, Delivery{
+				Message: msg,
+				Acknowledger: &redisAcknowledger{
+					broker:   r,
+					inFlight: inFlight,
+					id:       entry.ID,
+					msg:      msg,
+				},
+			})
+		}
+	}
+
+	var lastClaim time.Time
+	for {
+		if time.Since(lastClaim) >= r.opts.ClaimMinIdle/2 {
+			lastClaim = time.Now()
+			if err := r.claim(ctx, deliver); err != nil && !r.stopped(ctx, err) {
+				log.Printf("Failed to claim pending entries: %v", err)
+			}
+		}
+
+		streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    r.opts.Group,
+			Consumer: r.opts.Consumer,
+			Streams:  []string{r.opts.Stream, ">"},
+			Count:    r.opts.Count,
+			Block:    5 * time.Second,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if r.stopped(ctx, err) {
+				return nil
+			}
+			log.Printf("Failed to read from stream: %v", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		for _, stream := range streams {
+			deliver(stream.Messages)
+		}
+	}
+}
+
+// claim takes over every entry that has been pending for at least
+// ClaimMinIdle, e.g. because the consumer that read it crashed.
+func (r *Redis) claim(ctx context.Context, deliver func([]redis.XMessage)) error {
+	start := "0-0"
+	for {
+		entries, next, err := r.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   r.opts.Stream,
+			Group:    r.opts.Group,
+			Consumer: r.opts.Consumer,
+			MinIdle:  r.opts.ClaimMinIdle,
+			Start:    start,
+			Count:    r.opts.Count,
+		}).Result()
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			log.Printf("Claimed %d pending entries from %s", len(entries), r.opts.Stream)
+			deliver(entries)
+		}
+		if next == "0-0" {
+			return nil
+		}
+		start = next
+	}
+}
+
+// refresh resets the idle time of every entry in inFlight until ctx is done.
+// Entries another consumer has claimed in the meantime are dropped from
+// inFlight; settling them later is harmless because the outbox deduplicates.
+func (r *Redis) refresh(ctx context.Context, inFlight *redisInFlight) {
+	ticker := time.NewTicker(r.opts.ClaimMinIdle / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ids := inFlight.list()
+		if len(ids) == 0 {
+			continue
+		}
+		args := make([]any, 0, len(ids)+2)
+		args = append(args, r.opts.Group, r.opts.Consumer)
+		for _, id := range ids {
+			args = append(args, id)
+		}
+
+		lost, err := redisRefreshScript.Run(ctx, r.client, []string{r.opts.Stream}, args...).StringSlice()
+		if err != nil {
+			if !r.stopped(ctx, err) {
+				log.Printf("Failed to refresh pending entries: %v", err)
+			}
+			continue
+		}
+		for _, id := range lost {
+			log.Printf("Stream entry %s was claimed by another consumer", id)
+			inFlight.remove(id)
+		}
+	}
+}
+
+func (r *Redis) stopped(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, redis.ErrClosed)
+}
+
+func (r *Redis) Close() {
+	if err := r.client.Close(); err != nil && !errors.Is(err, redis.ErrClosed) {
+		fmt.Println("Queue close error:", err)
+	}
+}
+
+// settle atomically appends msg to stream, when set, and acknowledges and
+// deletes id so that a message is never lost or duplicated between the two.
+func (r *Redis) settle(ctx context.Context, id, stream string, msg Message) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if stream != "" {
+			values, err := redisValues(msg)
+			if err != nil {
+				return err
+			}
+			args := &redis.XAddArgs{
+				Stream: stream,
+				Values: values,
+			}
+			if stream == r.opts.DeadLetterStream && r.opts.DeadLetterMaxLen > 0 {
+				args.MaxLen = r.opts.DeadLetterMaxLen
+				args.Approx = true
+			}
+			pipe.XAdd(ctx, args)
+		}
+		pipe.XAck(ctx, r.opts.Stream, r.opts.Group, id)
+		pipe.XDel(ctx, r.opts.Stream, id)
+		return nil
+	})
+	return err
+}
+
+// redisInFlight is the set of entry IDs a subscriber has read but not yet
+// settled.
+type redisInFlight struct {
+	mu  sync.Mutex
+	ids map[string]struct{}
+}
+
+func newRedisInFlight() *redisInFlight {
+	return &redisInFlight{ids: make(map[string]struct{})}
+}
+
+func (f *redisInFlight) add(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.ids[id] = struct{}{}
+}
+
+func (f *redisInFlight) remove(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.ids, id)
+}
+
+func (f *redisInFlight) list() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := make([]string, 0, len(f.ids))
+	for id := range f.ids {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+type redisAcknowledger struct {
+	broker   *Redis
+	inFlight *redisInFlight
+	id       string
+	msg      Message
+}
+
+func (a *redisAcknowledger) Ack() error {
+	defer a.inFlight.remove(a.id)
+	return a.broker.settle(context.Background(), a.id, "", Message{})
+}
+
+func (a *redisAcknowledger) Nack(requeue bool) error {
+	if requeue {
+		defer a.inFlight.remove(a.id)
+		return a.broker.settle(context.Background(), a.id, a.broker.opts.Stream, a.msg)
+	}
+	return a.DeadLetter(context.Background(), nil)
+}
+
+// DeadLetter appends the message to DeadLetterStream and acknowledges it. If
+// no dead-letter stream is configured the message is dropped, but it is still
+// acknowledged so that it does not stay pending and get claimed forever.
+func (a *redisAcknowledger) DeadLetter(ctx context.Context, headers map[string]any) error {
+	defer a.inFlight.remove(a.id)
+
+	if a.broker.opts.DeadLetterStream == "" {
+		log.Printf("Dropping stream entry %s: no dead-letter stream configured", a.id)
+		return a.broker.settle(ctx, a.id, "", Message{})
+	}
+
+	dead := copyMessage(a.msg)
+	for k, v := range headers {
+		dead.Headers[k] = v
+	}
+	dead.Headers["x-original-stream"] = a.broker.opts.Stream
+	dead.Headers["x-original-id"] = a.id
+
+	if err := a.broker.settle(ctx, a.id, a.broker.opts.DeadLetterStream, dead); err != nil {
+		return fmt.Errorf("failed to dead-letter message: %w", err)
+	}
+	return nil
+}
+
+func redisValues(msg Message) (map[string]any, error) {
+	headers, err := json.Marshal(msg.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode headers: %w", err)
+	}
+	return map[string]any{
+		redisBodyField:    msg.Body,
+		redisKeyField:     msg.Key,
+		redisSubjectField: msg.Subject,
+		redisHeadersField: headers,
+	}, nil
+}
+
+func redisMessage(entry redis.XMessage) (Message, error) {
+	field := func(name string) string {
+		s, _ := entry.Values[name].(string)
+		return s
+	}
+
+	msg := Message{
+		Key:     field(redisKeyField),
+		Subject: field(redisSubjectField),
+		Body:    []byte(field(redisBodyField)),
+		Headers: map[string]any{},
+	}
+	if headers := field(redisHeadersField); headers != "" {
+		if err := json.Unmarshal([]byte(headers), &msg.Headers); err != nil {
+			return msg, fmt.Errorf("failed to decode headers: %w", err)
+		}
+	}
+	return msg, nil
+}
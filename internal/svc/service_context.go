@@ -1,19 +1,111 @@
 package svc
 
 import (
-	"github.com/jackc/pgx/v5"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/petechu/idempotent-webhook-relay/internal/config"
 	"github.com/petechu/idempotent-webhook-relay/internal/db"
+	"github.com/petechu/idempotent-webhook-relay/internal/provider"
+	"github.com/petechu/idempotent-webhook-relay/internal/queue"
 )
 
 type ServiceContext struct {
-	Config   *config.Config
-	OutboxDB *db.Queries
+	Config    *config.Config
+	DB        *pgxpool.Pool
+	OutboxDB  *db.Queries
+	Providers *provider.Registry
+	// Broker is set when the process also runs the producer and consumer,
+	// so that /healthz reports the broker connection too.
+	Broker queue.Broker
 }
 
-func NewServiceContext(cfg *config.Config, conn *pgx.Conn) *ServiceContext {
+func NewServiceContext(cfg *config.Config, pool *pgxpool.Pool) (*ServiceContext, error) {
+	providers, err := newProviderRegistry(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ServiceContext{
-		Config:   cfg,
-		OutboxDB: db.New(conn),
+		Config:    cfg,
+		DB:        pool,
+		OutboxDB:  db.New(pool),
+		Providers: providers,
+	}, nil
+}
+
+func newProviderRegistry(cfg *config.Config) (*provider.Registry, error) {
+	registry := provider.NewRegistry()
+
+	stripe := provider.NewStripe(provider.StripeOptions{
+		Secrets: stripeSecrets(cfg.StripeWebhookSecret, cfg.StripePreviousWebhookSecrets),
+	})
+	if err := registry.Register(stripe); err != nil {
+		return nil, err
+	}
+
+	for i, endpoint := range cfg.StripeEndpoints {
+		if endpoint.Name == "" || endpoint.Secret == "" {
+			return nil, fmt.Errorf("stripe endpoint %d requires a name and a secret", i)
+		}
+		stripe := provider.NewStripe(provider.StripeOptions{
+			Endpoint:          endpoint.Name,
+			Secrets:           stripeSecrets(endpoint.Secret, endpoint.PreviousSecrets),
+			AllowedEventTypes: endpoint.AllowedEventTypes,
+		})
+		if err := registry.RegisterEndpoint(endpoint.Name, stripe); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.GitHubWebhookSecret != "" {
+		if err := registry.Register(provider.NewGitHub(cfg.GitHubWebhookSecret)); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, endpoint := range cfg.StandardWebhooks {
+		if endpoint.Name == "" || endpoint.Secret == "" {
+			return nil, fmt.Errorf("standard webhook endpoint %d requires a name and a secret", i)
+		}
+		p, err := provider.NewStandardWebhooks(endpoint.Name, endpoint.Secret, endpoint.Tolerance)
+		if err != nil {
+			return nil, err
+		}
+		if err := registry.Register(p); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, endpoint := range cfg.HMACWebhooks {
+		p, err := provider.NewHMAC(provider.HMACOptions{
+			Name:          endpoint.Name,
+			Secret:        endpoint.Secret,
+			Header:        endpoint.Header,
+			Algorithm:     endpoint.Algorithm,
+			Encoding:      endpoint.Encoding,
+			Prefix:        endpoint.Prefix,
+			EventIDPath:   endpoint.EventIDPath,
+			EventTypePath: endpoint.EventTypePath,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := registry.Register(p); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+func stripeSecrets(current string, previous []config.RotatedSecret) []provider.StripeSecret {
+	secrets := []provider.StripeSecret{{Value: current}}
+	for _, secret := range previous {
+		secrets = append(secrets, provider.StripeSecret{
+			Value:     secret.Secret,
+			ExpiresAt: secret.ExpiresAt,
+		})
 	}
+	return secrets
 }
@@ -14,12 +14,33 @@ func Must[T any](value T, err error) T {
 	return value
 }
 
+// PermanentError stops Backoff from retrying fn.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func Permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
 func Backoff(fn func() error, maxRetries int) error {
 	var errs []error
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if err := fn(); err != nil {
 			errs = append(errs, err)
+			var permanent *PermanentError
+			if errors.As(err, &permanent) {
+				break
+			}
 			if attempt < maxRetries {
 				maxBackoff := 30 * time.Second
 				baseBackoff := min(time.Duration(math.Pow(2, float64(attempt)))*time.Second, maxBackoff)
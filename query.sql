@@ -1,21 +1,53 @@
 -- name: GetOutBoxEvent :one
 SELECT * FROM outbox
-WHERE event_id = $1;
+WHERE provider = $1 AND event_id = $2;
 
 -- name: ListEvents :many
 SELECT * FROM outbox;
 
 -- name: ListUnprocessedEvents :many
 SELECT * FROM outbox
-WHERE COALESCE(status, '') NOT IN ('pending', 'processed')
+WHERE COALESCE(status, '') NOT IN ('pending', 'processed', 'ignored', 'dead_lettered')
 AND type = ANY($1::varchar[]);
 
+-- name: ClaimUnprocessedEvents :many
+UPDATE outbox
+SET
+  claimed_by = @claimed_by,
+  claim_expires_at = @claim_expires_at
+WHERE id IN (
+  SELECT id FROM outbox
+  WHERE COALESCE(status, '') NOT IN ('pending', 'processed', 'ignored', 'dead_lettered')
+  AND (claim_expires_at IS NULL OR claim_expires_at < NOW())
+  ORDER BY id
+  LIMIT @batch_size
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ClaimOutboxEvents :many
+UPDATE outbox
+SET
+  status = 'pending',
+  claimed_by = @claimed_by,
+  claim_expires_at = @claim_expires_at
+WHERE id IN (
+  SELECT id FROM outbox
+  WHERE COALESCE(status, '') NOT IN ('processed', 'ignored', 'dead_lettered')
+  AND (claim_expires_at IS NULL OR claim_expires_at < NOW())
+  ORDER BY id
+  LIMIT @batch_size
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
 -- name: ListFailedEvents :many
 SELECT * FROM outbox
 WHERE status = 'failed';
 
 -- name: InsertOutboxEvent :one
-INSERT INTO outbox (event_id, type, payload, provider) VALUES ($1, $2, $3, $4)
+INSERT INTO outbox (event_id, type, payload, provider, endpoint, account) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (provider, event_id) DO NOTHING
 RETURNING id;
 
 -- name: UpdateOutboxEvent :exec
@@ -25,3 +57,144 @@ SET
   last_error = $3,
   last_attempt_at = $4
 WHERE id = $1;
+
+-- name: MarkOutboxPublished :exec
+UPDATE outbox
+SET
+  status = 'pending',
+  last_error = NULL,
+  last_attempt_at = NULL
+WHERE id = $1
+AND claimed_by = $2
+AND COALESCE(status, '') NOT IN ('processed', 'process_failed', 'dead_lettered');
+
+-- name: RecordOutboxResponse :exec
+UPDATE outbox
+SET
+  response_status = $2,
+  response_body = $3
+WHERE id = $1;
+
+-- name: ReleaseOutboxClaim :exec
+UPDATE outbox
+SET claim_expires_at = NULL
+WHERE id = $1;
+
+-- name: ResetOutboxEvent :execrows
+UPDATE outbox
+SET
+  status = NULL,
+  claimed_by = NULL,
+  claim_expires_at = NULL
+WHERE id = $1 AND status = 'dead_lettered';
+
+-- name: ResetPendingOutboxEvents :execrows
+UPDATE outbox
+SET
+  status = NULL,
+  claimed_by = NULL,
+  claim_expires_at = NULL
+WHERE status = 'pending';
+
+-- name: InsertDeadLetter :one
+INSERT INTO dead_letters (outbox_id, event_id, payload, attempts, errors, last_error)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id;
+
+-- name: GetDeadLetter :one
+SELECT * FROM dead_letters
+WHERE id = $1;
+
+-- name: ListDeadLetters :many
+SELECT * FROM dead_letters
+WHERE redriven_at IS NULL
+ORDER BY id;
+
+-- name: MarkDeadLetterRedriven :execrows
+UPDATE dead_letters
+SET redriven_at = NOW()
+WHERE id = $1 AND redriven_at IS NULL;
+
+-- name: MarkOutboxDeadLettered :exec
+UPDATE outbox
+SET
+  status = 'dead_lettered',
+  claim_expires_at = NULL
+WHERE id = $1;
+
+-- name: CreateSubscription :one
+INSERT INTO subscriptions (url, provider, event_types, enabled, secret)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetSubscription :one
+SELECT * FROM subscriptions
+WHERE id = $1;
+
+-- name: ListSubscriptions :many
+SELECT * FROM subscriptions
+ORDER BY id;
+
+-- name: ListMatchingSubscriptions :many
+SELECT * FROM subscriptions
+WHERE enabled
+AND (provider IS NULL OR provider = @provider::text)
+ORDER BY id;
+
+-- name: UpdateSubscription :one
+UPDATE subscriptions
+SET
+  url = $2,
+  provider = $3,
+  event_types = $4,
+  enabled = $5,
+  secret = $6,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteSubscription :execrows
+DELETE FROM subscriptions
+WHERE id = $1;
+
+-- name: EnsureSubscriptionDelivery :exec
+INSERT INTO subscription_deliveries (outbox_id, subscription_id)
+VALUES ($1, $2)
+ON CONFLICT (outbox_id, subscription_id) DO NOTHING;
+
+-- name: GetSubscriptionDelivery :one
+SELECT * FROM subscription_deliveries
+WHERE outbox_id = $1 AND subscription_id = $2;
+
+-- name: UpdateSubscriptionDelivery :exec
+UPDATE subscription_deliveries
+SET
+  status = $2,
+  attempts = attempts + 1,
+  response_status = $3,
+  response_body = $4,
+  last_error = $5,
+  last_attempt_at = NOW(),
+  delivered_at = $6
+WHERE id = $1;
+
+-- name: ListSubscriptionDeliveries :many
+SELECT * FROM subscription_deliveries
+WHERE outbox_id = $1
+ORDER BY id;
+
+-- name: IncrementOutboxRetryCount :one
+UPDATE outbox
+SET retry_count = retry_count + 1
+WHERE id = $1
+RETURNING retry_count;
+
+-- name: InsertDeliveryAttempt :exec
+INSERT INTO delivery_attempts (
+  outbox_id, subscription_id, url, attempt, started_at, finished_at, latency_ms, outcome, http_status, error
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: ListDeliveryAttempts :many
+SELECT * FROM delivery_attempts
+WHERE outbox_id = $1
+ORDER BY id;